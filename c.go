@@ -79,8 +79,12 @@ type coreValues struct {
 	appNameProvider         AppNameProvider
 	envProvider             EnvProvider
 	loggerProvider          LoggerProvider
+	containerProvider       ContainerProvider
 }
 
+// ContainerProvider provides the contract.Container to the core.
+type ContainerProvider func(conf contract.ConfigAccessor) contract.Container
+
 // CoreOption is the option to modify core attribute.
 type CoreOption func(*coreValues)
 
@@ -160,6 +164,18 @@ func SetDiProvider(provider DiProvider) CoreOption {
 	}
 }
 
+// SetContainerProvider is a CoreOption to replaces the default ContainerProvider.
+// For example, to name the offending module when a module panics:
+//
+//	core.New(core.SetContainerProvider(func(conf contract.ConfigAccessor) contract.Container {
+//		return container.New(container.WithPanicRecovery())
+//	}))
+func SetContainerProvider(provider ContainerProvider) CoreOption {
+	return func(values *coreValues) {
+		values.containerProvider = provider
+	}
+}
+
 // SetEventDispatcherProvider is a CoreOption to replaces the default EventDispatcherProvider.
 func SetEventDispatcherProvider(provider EventDispatcherProvider) CoreOption {
 	return func(values *coreValues) {
@@ -188,13 +204,19 @@ func New(opts ...CoreOption) *C {
 	logger := values.loggerProvider(conf, appName, env)
 	diContainer := values.diProvider(conf)
 	dispatcher := values.eventDispatcherProvider(conf)
+	var cont contract.Container
+	if values.containerProvider != nil {
+		cont = values.containerProvider(conf)
+	} else {
+		cont = container.New(container.WithLogger(logger))
+	}
 
 	var c = C{
 		AppName:        appName,
 		Env:            env,
 		ConfigAccessor: conf,
 		LevelLogger:    logging.WithLevel(logger),
-		Container:      &container.Container{},
+		Container:      cont,
 		Dispatcher:     dispatcher,
 		di:             diContainer,
 	}
@@ -236,6 +258,27 @@ func (c *C) AddModule(modules ...interface{}) {
 	}
 }
 
+// AddNamespacedModule adds a module under a configuration namespace. Modules
+// implementing container.NamespaceAware receive the namespace and can use it to
+// scope their configuration lookups, which allows running several instances of
+// the same module side by side. It panics if the module is an error, same as
+// AddModule.
+func (c *C) AddNamespacedModule(ns string, module interface{}) {
+	if err, ok := module.(error); ok {
+		panic(err)
+	}
+	if nc, ok := c.Container.(interface {
+		AddNamespacedModule(ns string, module interface{})
+	}); ok {
+		nc.AddNamespacedModule(ns, module)
+		return
+	}
+	if p, ok := module.(container.NamespaceAware); ok {
+		p.SetNamespace(ns)
+	}
+	c.Container.AddModule(module)
+}
+
 // Provide adds a dependencies provider to the core. Note the dependency provider
 // must be a function in the form of:
 //
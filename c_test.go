@@ -1,8 +1,11 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"sync/atomic"
@@ -11,6 +14,7 @@ import (
 
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/config/remote"
+	"github.com/DoNewsCode/core/container"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
 	"github.com/DoNewsCode/core/events"
@@ -18,6 +22,8 @@ import (
 	"github.com/DoNewsCode/core/srvgrpc"
 	"github.com/DoNewsCode/core/srvhttp"
 
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/client/v3"
@@ -61,11 +67,63 @@ func TestC_Serve(t *testing.T) {
 			return nil
 		}))
 	})
+	transitions := c.Container.(*container.Container).Transitions()
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 	defer cancel()
 	e := c.Serve(ctx)
 	assert.NoError(t, e)
 	assert.Equal(t, int32(4), atomic.LoadInt32(&called))
+	assert.Equal(t, container.Draining, c.Container.(*container.Container).State())
+	c.Shutdown()
+	var states []container.State
+	for transition := range transitions {
+		states = append(states, transition.To)
+	}
+	assert.Equal(t, []container.State{container.Built, container.Starting, container.Running, container.Draining, container.Stopped}, states)
+}
+
+func TestC_ServeMetrics(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []CoreOption
+		path string
+		code int
+	}{
+		{"default", nil, "/metrics", http.StatusOK},
+		{"custom path", []CoreOption{WithInline("http.metrics.path", "/custom")}, "/custom", http.StatusOK},
+		{"disabled", []CoreOption{WithInline("http.metrics.disable", "true")}, "/metrics", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			opts := append([]CoreOption{
+				WithInline("http.addr", ":19997"),
+				WithInline("grpc.disable", "true"),
+				WithInline("cron.disable", "true"),
+			}, c.opts...)
+			core := New(opts...)
+			core.ProvideEssentials()
+			core.Provide(di.Deps{func() prometheus.Gatherer {
+				registry := prometheus.NewRegistry()
+				registry.MustRegister(prometheus.NewCounter(prometheus.CounterOpts{Name: "foo_total"}))
+				return registry
+			}})
+
+			var code int32
+			core.Invoke(func(dispatcher contract.Dispatcher) {
+				dispatcher.Subscribe(events.Listen(events.From(OnHTTPServerStart{}), func(ctx context.Context, start contract.Event) error {
+					recorder := httptest.NewRecorder()
+					request := httptest.NewRequest(http.MethodGet, c.path, nil)
+					start.Data().(OnHTTPServerStart).HTTPServer.Handler.ServeHTTP(recorder, request)
+					atomic.StoreInt32(&code, int32(recorder.Code))
+					return nil
+				}))
+			})
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			assert.NoError(t, core.Serve(ctx))
+			assert.Equal(t, int32(c.code), atomic.LoadInt32(&code))
+		})
+	}
 }
 
 func TestC_ServeDisable(t *testing.T) {
@@ -131,6 +189,21 @@ func TestC_Default(t *testing.T) {
 	assert.Contains(t, string(output), "gorm:")
 }
 
+func TestC_ShutdownContext(t *testing.T) {
+	var buf bytes.Buffer
+	c := New(SetLoggerProvider(func(contract.ConfigAccessor, contract.AppName, contract.Env) log.Logger {
+		return log.NewLogfmtLogger(&buf)
+	}))
+	release := make(chan struct{})
+	defer close(release)
+	c.AddModule(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	c.Container.(*container.Container).ShutdownContext(ctx)
+	assert.Contains(t, buf.String(), "closers still running")
+}
+
 func TestC_Remote(t *testing.T) {
 	addr := os.Getenv("ETCD_ADDR")
 	if addr == "" {
@@ -0,0 +1,77 @@
+package clihttp
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Do without making the request, if the circuit
+// breaker of the request host is open.
+var ErrCircuitOpen = errors.New("clihttp: circuit breaker is open")
+
+// WithCircuitBreaker is an option that short-circuits the requests to hosts
+// that keep failing. A request fails if it returns an error or a 5xx response.
+// After the given number of consecutive failures to a host, the breaker of that
+// host opens, and Do returns ErrCircuitOpen without making the request. Once
+// cooldown has elapsed, a single trial request is let through: if it succeeds
+// the breaker closes, otherwise it opens for another cooldown.
+//
+// Breakers are kept per host, so that one bad dependency doesn't trip the calls
+// to the others.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(client *Client) {
+		client.breakers = &breakers{failures: failures, cooldown: cooldown, hosts: make(map[string]*breaker)}
+	}
+}
+
+type breakers struct {
+	failures int
+	cooldown time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*breaker
+}
+
+// breaker is the state of a single host.
+type breaker struct {
+	failures  int
+	openUntil time.Time
+	trial     bool
+}
+
+// allow reports whether a request to the host can be made.
+func (b *breakers) allow(host string, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.hosts[host]
+	if !ok || state.failures < b.failures {
+		return true
+	}
+	if now.Before(state.openUntil) || state.trial {
+		return false
+	}
+	state.trial = true
+	return true
+}
+
+// record records the outcome of a request to the host.
+func (b *breakers) record(host string, response *http.Response, err error, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.hosts[host]
+	if !ok {
+		state = &breaker{}
+		b.hosts[host] = state
+	}
+	state.trial = false
+	if err == nil && response.StatusCode < http.StatusInternalServerError {
+		state.failures = 0
+		return
+	}
+	state.failures++
+	if state.failures >= b.failures {
+		state.openUntil = now.Add(b.cooldown)
+	}
+}
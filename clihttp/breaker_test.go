@@ -0,0 +1,71 @@
+package clihttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+	var (
+		calls   int32
+		healthy int32
+	)
+	bad := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {}))
+	defer good.Close()
+
+	tracer := mocktracer.New()
+	client := NewClient(tracer, WithCircuitBreaker(3, 50*time.Millisecond))
+	get := func(url string) (*http.Response, error) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := get(bad.URL)
+		assert.NoError(t, err)
+	}
+	_, err := get(bad.URL)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, true, spans[len(spans)-1].Tag("circuit.open"))
+
+	// other hosts are not affected.
+	_, err = get(good.URL)
+	assert.NoError(t, err)
+
+	// the trial request fails and opens the breaker again.
+	time.Sleep(60 * time.Millisecond)
+	_, err = get(bad.URL)
+	assert.NoError(t, err)
+	_, err = get(bad.URL)
+	assert.Equal(t, ErrCircuitOpen, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+
+	// the trial request succeeds and closes the breaker.
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	_, err = get(bad.URL)
+	assert.NoError(t, err)
+	_, err = get(bad.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
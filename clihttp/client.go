@@ -5,11 +5,16 @@ package clihttp
 
 import (
 	"bytes"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/logging"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/opentracing-contrib/go-stdlib/nethttp"
 	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/ext"
@@ -28,6 +33,20 @@ type Client struct {
 	underlying           contract.HttpDoer
 	requestLogThreshold  int
 	responseLogThreshold int
+	chunkedThreshold     int64
+	singleFlight         *singleFlight
+	slowThreshold        time.Duration
+	slowLogger           kitlog.Logger
+	validator            func(*http.Response, []byte) error
+	breakers             *breakers
+	redactedHeaders      []string
+	logHeaders           bool
+	logger               kitlog.Logger
+	logKeys              contract.Keyer
+	transport            http.RoundTripper
+	timeout              time.Duration
+	maxLogBytes          int
+	metrics              *clientMetrics
 }
 
 // Option changes the behavior of Client.
@@ -52,22 +71,101 @@ func WithRequestLogThreshold(num int) Option {
 // If the response body is larger than this threshold, the log will be omit.
 func WithResponseLogThreshold(num int) Option {
 	return func(client *Client) {
-		client.requestLogThreshold = num
+		client.responseLogThreshold = num
+	}
+}
+
+// WithMaxLogBytes is an option that truncates the logged request and response
+// bodies to num bytes, followed by a "...(truncated)" marker. Unlike the log
+// thresholds, which decide whether a body is logged at all, it only limits how
+// much of it is. There is no limit by default.
+func WithMaxLogBytes(num int) Option {
+	return func(client *Client) {
+		client.maxLogBytes = num
+	}
+}
+
+// WithChunkedThreshold is an option that decides how request bodies are framed
+// based on their size in bytes. Bodies larger than the threshold are sent with
+// chunked Transfer-Encoding, and smaller ones with a known Content-Length. For
+// bodies of unknown length, up to threshold bytes are buffered to find out
+// which side they fall on.
+//
+// By default (or with a threshold of zero), the decision is left to net/http:
+// bodies of known length get a Content-Length and the others are chunked.
+//
+// The threshold applies to the body as given to the client, so compress the
+// body beforehand if the compressed size should count. Streamed chunked bodies
+// can't be replayed, so they are neither logged nor retried by the transport.
+func WithChunkedThreshold(bytes int64) Option {
+	return func(client *Client) {
+		client.chunkedThreshold = bytes
+	}
+}
+
+// WithSlowRequestThreshold is an option that logs requests taking longer than
+// the threshold at warn level, with the method, url, duration and the trace id
+// if available. It is disabled by default.
+func WithSlowRequestThreshold(threshold time.Duration, logger kitlog.Logger) Option {
+	return func(client *Client) {
+		client.slowThreshold = threshold
+		client.slowLogger = logger
+	}
+}
+
+// WithResponseValidator is an option that inspects every 2xx response, for
+// upstreams that report errors in the body of a successful response. If the
+// validator returns an error, the response is closed, the span is marked as
+// failed, and Do returns the error.
+//
+// The validator receives the whole body as bytes, and the caller still gets an
+// unread copy of it. Note this means every 2xx response body is buffered in
+// memory before Do returns, so beware of large or streamed responses.
+func WithResponseValidator(validator func(*http.Response, []byte) error) Option {
+	return func(client *Client) {
+		client.validator = validator
+	}
+}
+
+// WithTransport is an option that sets the transport of the underlying
+// http.Client, for example to configure the connection pool, the proxy or the
+// TLS settings. The transport is wrapped by the tracing transport, not replaced
+// by it. It has no effect if WithDoer is given. The default is
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(client *Client) {
+		client.transport = transport
+	}
+}
+
+// WithTimeout is an option that sets the timeout of the underlying http.Client,
+// which bounds the whole request including reading the response body. It has
+// no effect if WithDoer is given. There is no timeout by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(client *Client) {
+		client.timeout = timeout
 	}
 }
 
 // NewClient creates a Client with tracing support.
 func NewClient(tracer opentracing.Tracer, options ...Option) *Client {
-	baseClient := &http.Client{Transport: &nethttp.Transport{}}
+	baseClient := &http.Client{}
 	c := &Client{
 		tracer:               tracer,
 		underlying:           baseClient,
 		requestLogThreshold:  5000,
 		responseLogThreshold: 5000,
+		redactedHeaders:      defaultRedactedHeaders,
 	}
 	for _, f := range options {
 		f(c)
 	}
+	transport := c.transport
+	if c.metrics != nil {
+		transport = &metricsTransport{next: transport, metrics: c.metrics}
+	}
+	baseClient.Transport = &nethttp.Transport{RoundTripper: transport}
+	baseClient.Timeout = c.timeout
 	return c
 }
 
@@ -82,24 +180,149 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	ext.HTTPUrl.Set(clientSpan, req.RequestURI)
 	ext.HTTPMethod.Set(clientSpan, req.Method)
 
-	// Inject the client span context into the headers
-	c.logRequest(req, clientSpan)
+	if c.chunkedThreshold > 0 {
+		if err := c.frameBody(req); err != nil {
+			ext.Error.Set(clientSpan, true)
+			clientSpan.LogFields(log.Error(err))
+			return nil, err
+		}
+	}
+
+	if c.breakers != nil && !c.breakers.allow(req.URL.Host, time.Now()) {
+		ext.Error.Set(clientSpan, true)
+		clientSpan.SetTag("circuit.open", true)
+		return nil, ErrCircuitOpen
+	}
+
+	logSpan := c.loggingSpan(clientSpan, req.Method, req.URL.String())
 
+	c.logRequest(req, logSpan)
+
+	// Inject the client span context into the headers, so that the downstream
+	// service can continue the trace.
 	c.tracer.Inject(clientSpan.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
-	response, err := c.underlying.Do(req)
+
+	var (
+		response *http.Response
+		err      error
+		begin    = time.Now()
+	)
+	if c.singleFlight != nil && c.singleFlight.eligible(req) {
+		var shared bool
+		response, shared, err = c.singleFlight.do(c.underlying, req)
+		clientSpan.SetTag("http.shared", shared)
+	} else {
+		response, err = c.underlying.Do(req)
+	}
+	if c.slowThreshold > 0 && c.slowLogger != nil {
+		c.logSlowRequest(req, time.Since(begin), err)
+	}
+	if c.breakers != nil {
+		c.breakers.record(req.URL.Host, response, err, time.Now())
+	}
 	if err != nil {
 		return response, err
 	}
 
-	c.logResponse(response, clientSpan)
+	c.logResponse(response, logSpan)
+
+	if c.validator != nil && response.StatusCode >= 200 && response.StatusCode < 300 {
+		if err := c.validateResponse(response); err != nil {
+			ext.Error.Set(clientSpan, true)
+			clientSpan.LogFields(log.Error(err))
+			return nil, err
+		}
+	}
 
 	return response, err
 }
 
+// validateResponse buffers the response body and runs the validator on it. The
+// body is restored for the caller unless the validation fails.
+func (c *Client) validateResponse(response *http.Response) error {
+	var byt []byte
+	if response.Body != nil {
+		var err error
+		byt, err = ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		if err != nil {
+			return errors.Wrap(err, "cannot read response body")
+		}
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(byt))
+	if err := c.validator(response, byt); err != nil {
+		return err
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(byt))
+	return nil
+}
+
+func (c *Client) logSlowRequest(req *http.Request, duration time.Duration, err error) {
+	if duration <= c.slowThreshold {
+		return
+	}
+	keyvals := []interface{}{
+		"msg", "slow http request",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"duration", duration,
+		"traceId", logging.TraceID(req.Context()),
+	}
+	if err != nil {
+		keyvals = append(keyvals, "err", err)
+	}
+	_ = level.Warn(c.slowLogger).Log(keyvals...)
+}
+
+// frameBody switches the request between a known Content-Length and chunked
+// Transfer-Encoding according to the chunked threshold.
+func (c *Client) frameBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.ContentLength > 0 {
+		if req.ContentLength > c.chunkedThreshold {
+			req.ContentLength = -1
+			req.Header.Del("Content-Length")
+		}
+		return nil
+	}
+	// unknown length, peek at most threshold+1 bytes to decide.
+	buf := bytes.NewBuffer(nil)
+	_, err := io.CopyN(buf, req.Body, c.chunkedThreshold+1)
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "cannot read request body")
+	}
+	if int64(buf.Len()) <= c.chunkedThreshold {
+		req.Body.Close()
+		byt := buf.Bytes()
+		req.ContentLength = int64(len(byt))
+		req.Body = ioutil.NopCloser(bytes.NewReader(byt))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(byt)), nil
+		}
+		return nil
+	}
+	req.ContentLength = -1
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(buf, req.Body), req.Body}
+	req.GetBody = nil
+	return nil
+}
+
 func (c *Client) logRequest(req *http.Request, span opentracing.Span) {
+	if c.logHeaders {
+		span.LogKV("request.headers", c.redactHeaders(req.Header))
+	}
 	if req.Body == nil {
 		return
 	}
+	if req.GetBody == nil {
+		span.LogKV("request", "elided: body not replayable")
+		return
+	}
 	body, err := req.GetBody()
 	if err != nil {
 		ext.Error.Set(span, true)
@@ -119,29 +342,52 @@ func (c *Client) logRequest(req *http.Request, span opentracing.Span) {
 		return
 	}
 	if span != nil {
-		span.LogKV("request", string(byt))
+		span.LogKV("request", c.truncate(byt))
 	}
 
 }
 
+// logResponse logs the response headers if enabled, and the response body if it is within
+// the response log threshold. The body is restored afterwards, so that the caller still
+// receives the complete payload.
 func (c *Client) logResponse(response *http.Response, span opentracing.Span) {
-	if response.Body == nil {
+	if c.logHeaders {
+		span.LogKV("response.headers", c.redactHeaders(response.Header))
+	}
+	if response.Body == nil || response.Body == http.NoBody {
 		return
 	}
-	length, _ := strconv.Atoi(response.Header.Get(http.CanonicalHeaderKey("Content-Length")))
-	if length > c.responseLogThreshold {
+	if response.ContentLength > int64(c.responseLogThreshold) {
 		span.LogKV("response", "elided: Content-Length too large")
 		return
 	}
+	// the length may be unknown, peek at most threshold+1 bytes to decide.
 	var buf bytes.Buffer
-	byt, err := ioutil.ReadAll(response.Body)
-	if err != nil {
+	_, err := io.CopyN(&buf, response.Body, int64(c.responseLogThreshold)+1)
+	if err != nil && err != io.EOF {
 		ext.Error.Set(span, true)
 		span.LogFields(log.Error(err))
 	}
-	if span != nil {
-		span.LogKV("response", string(byt))
+	if (err != nil && err != io.EOF) || buf.Len() > c.responseLogThreshold {
+		if err == nil {
+			span.LogKV("response", "elided: body too large")
+		}
+		// hand the rest of the stream, including the read error, to the caller.
+		response.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(&buf, response.Body), response.Body}
+		return
 	}
-	buf.Write(byt)
+	span.LogKV("response", c.truncate(buf.Bytes()))
+	response.Body.Close()
 	response.Body = ioutil.NopCloser(&buf)
 }
+
+// truncate formats the body for logging, within the max log bytes.
+func (c *Client) truncate(body []byte) string {
+	if c.maxLogBytes > 0 && len(body) > c.maxLogBytes {
+		return string(body[:c.maxLogBytes]) + "...(truncated)"
+	}
+	return string(body)
+}
@@ -1,12 +1,20 @@
 package clihttp
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-kit/kit/log"
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/opentracing/opentracing-go/mocktracer"
@@ -77,3 +85,278 @@ func TestClient_context(t *testing.T) {
 	assert.Len(t, tracer.FinishedSpans(), 2)
 	assert.Equal(t, "bar", tracer.FinishedSpans()[1].BaggageItem("foo"))
 }
+
+func TestClient_ChunkedThreshold(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		writer.Header().Set("X-Chunked", strconv.FormatBool(len(request.TransferEncoding) > 0))
+		writer.Header().Set("X-Length", strconv.Itoa(len(body)))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name    string
+		body    io.Reader
+		chunked bool
+	}{
+		{"known small", strings.NewReader(strings.Repeat("t", 5)), false},
+		{"known large", strings.NewReader(strings.Repeat("t", 20)), true},
+		{"unknown small", ioutil.NopCloser(strings.NewReader(strings.Repeat("t", 10))), false},
+		{"unknown large", ioutil.NopCloser(strings.NewReader(strings.Repeat("t", 11))), true},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			client := NewClient(opentracing.NoopTracer{}, WithChunkedThreshold(10))
+			req, _ := http.NewRequest("POST", server.URL, c.body)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, strconv.FormatBool(c.chunked), resp.Header.Get("X-Chunked"))
+			assert.NotEqual(t, "0", resp.Header.Get("X-Length"))
+		})
+	}
+}
+
+func TestClient_SlowRequestThreshold(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient(opentracing.NoopTracer{}, WithSlowRequestThreshold(20*time.Millisecond, log.NewLogfmtLogger(&buf)))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/fast", nil)
+	_, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/slow", nil)
+	_, err = client.Do(req)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "level=warn")
+	assert.Contains(t, buf.String(), "method=GET")
+	assert.Contains(t, buf.String(), "/slow")
+}
+
+func TestClient_ResponseValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(`{"code":` + request.URL.Query().Get("code") + `}`))
+	}))
+	defer server.Close()
+
+	tracer := mocktracer.New()
+	client := NewClient(tracer, WithResponseValidator(func(response *http.Response, body []byte) error {
+		if !bytes.Contains(body, []byte(`"code":0`)) {
+			return errors.New("bad envelope")
+		}
+		return nil
+	}))
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"?code=0", nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	byt, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, `{"code":0}`, string(byt))
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"?code=1", nil)
+	resp, err = client.Do(req)
+	assert.EqualError(t, err, "bad envelope")
+	assert.Nil(t, resp)
+	spans := tracer.FinishedSpans()
+	assert.Equal(t, true, spans[len(spans)-1].Tag("error"))
+}
+
+func TestClient_ResponseLogThreshold(t *testing.T) {
+	t.Parallel()
+	payload := strings.Repeat("t", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Query().Get("chunked") != "" {
+			writer.(http.Flusher).Flush()
+		}
+		_, _ = writer.Write([]byte(payload))
+	}))
+	defer server.Close()
+
+	for _, query := range []string{"", "?chunked=1"} {
+		for _, threshold := range []int{1, 5000} {
+			tracer := mocktracer.New()
+			client := NewClient(tracer, WithResponseLogThreshold(threshold))
+			req, _ := http.NewRequest("GET", server.URL+query, nil)
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			byt, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+			assert.Equal(t, payload, string(byt))
+
+			logged := tracer.FinishedSpans()[0].Logs()
+			response := logged[len(logged)-1].Fields[0].ValueString
+			if threshold < len(payload) {
+				assert.Contains(t, response, "elided")
+			} else {
+				assert.Equal(t, payload, response)
+			}
+		}
+	}
+}
+
+func TestClient_RedactedHeaders(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Set-Cookie", "session=secret-session")
+		writer.Header().Set("X-Api-Key", "secret-key")
+		writer.Header().Set("X-Request-Id", "42")
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		options  []Option
+		secrets  []string
+		revealed []string
+	}{
+		{"disabled", nil, []string{"secret-token", "secret-session", "secret-key", "42"}, nil},
+		{"default", []Option{WithHeaderLogging(true)}, []string{"secret-token", "secret-session"}, []string{"secret-key", "42"}},
+		{"custom", []Option{WithHeaderLogging(true), WithRedactedHeaders("x-api-key", "AUTHORIZATION")}, []string{"secret-token", "secret-key"}, []string{"secret-session", "42"}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			tracer := mocktracer.New()
+			client := NewClient(tracer, c.options...)
+			req, _ := http.NewRequest("GET", server.URL, nil)
+			req.Header.Set("Authorization", "Bearer secret-token")
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			resp.Body.Close()
+
+			var logged strings.Builder
+			for _, record := range tracer.FinishedSpans()[0].Logs() {
+				for _, field := range record.Fields {
+					logged.WriteString(field.ValueString)
+				}
+			}
+			for _, secret := range c.secrets {
+				assert.NotContains(t, logged.String(), secret)
+			}
+			for _, value := range c.revealed {
+				assert.Contains(t, logged.String(), value)
+			}
+		})
+	}
+}
+
+func TestClient_Logger(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(strings.Repeat("t", 10)))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	client := NewClient(
+		opentracing.NoopTracer{},
+		WithLogger(log.NewLogfmtLogger(&buf)),
+		WithLogKeyPrefix("clihttp"),
+		WithResponseLogThreshold(5),
+	)
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Contains(t, buf.String(), "level=debug clihttp.method=GET clihttp.url="+server.URL)
+	assert.Contains(t, buf.String(), `clihttp.response="elided: Content-Length too large"`)
+}
+
+type recordingTransport struct {
+	called int32
+}
+
+func (r *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.called++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClient_Transport(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	transport := &recordingTransport{}
+	client := NewClient(opentracing.NoopTracer{}, WithTransport(transport), WithTimeout(50*time.Millisecond))
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), transport.called)
+
+	req, _ = http.NewRequest("GET", server.URL+"/slow", nil)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+}
+
+func TestClient_MaxLogBytes(t *testing.T) {
+	t.Parallel()
+	payload := strings.Repeat("t", 1<<20)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = io.Copy(writer, request.Body)
+	}))
+	defer server.Close()
+
+	tracer := mocktracer.New()
+	client := NewClient(
+		tracer,
+		WithRequestLogThreshold(2<<20),
+		WithResponseLogThreshold(2<<20),
+		WithMaxLogBytes(256),
+	)
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader(payload))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	byt, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, payload, string(byt))
+
+	logged := make(map[string]string)
+	for _, record := range tracer.FinishedSpans()[0].Logs() {
+		logged[record.Fields[0].Key] = record.Fields[0].ValueString
+	}
+	expected := strings.Repeat("t", 256) + "...(truncated)"
+	assert.Equal(t, expected, logged["request"])
+	assert.Equal(t, expected, logged["response"])
+}
+
+func TestClient_Propagation(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	var extracted mocktracer.MockSpanContext
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(request.Header))
+		if err == nil {
+			extracted = ctx.(mocktracer.MockSpanContext)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(tracer)
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	span := tracer.FinishedSpans()[0]
+	assert.NotZero(t, extracted.TraceID)
+	assert.Equal(t, span.SpanContext.TraceID, extracted.TraceID)
+	assert.Equal(t, span.SpanContext.SpanID, extracted.SpanID)
+	assert.Equal(t, strconv.Itoa(span.SpanContext.SpanID), req.Header.Get("Mockpfx-Ids-Spanid"))
+}
@@ -0,0 +1,72 @@
+package clihttp
+
+import (
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// WithLogger is an option that writes the request and response logs to the
+// logger at debug level, in addition to the span. Every line carries the method
+// and the url of the request. Without this option, the logs only go to the
+// span.
+func WithLogger(logger kitlog.Logger) Option {
+	return func(client *Client) {
+		client.logger = logger
+	}
+}
+
+// WithLogKeyPrefix is an option that prefixes the keys of the lines written to
+// the logger of WithLogger, so that they are attributable to the client. For
+// example, with the prefix "clihttp", the response is logged under the key
+// "clihttp.response".
+func WithLogKeyPrefix(prefix string) Option {
+	return func(client *Client) {
+		client.logKeys = key.New(prefix)
+	}
+}
+
+// loggingSpan is a span that writes its logs to a logger too.
+type loggingSpan struct {
+	opentracing.Span
+	logger kitlog.Logger
+	keys   contract.Keyer
+}
+
+func (c *Client) loggingSpan(span opentracing.Span, method, url string) opentracing.Span {
+	if c.logger == nil {
+		return span
+	}
+	keys := c.logKeys
+	if keys == nil {
+		keys = key.New()
+	}
+	logger := kitlog.With(level.Debug(c.logger), keys.Key(".", "method"), method, keys.Key(".", "url"), url)
+	return &loggingSpan{Span: span, logger: logger, keys: keys}
+}
+
+// LogKV implements opentracing.Span.
+func (s *loggingSpan) LogKV(alternatingKeyValues ...interface{}) {
+	s.Span.LogKV(alternatingKeyValues...)
+	keyvals := make([]interface{}, len(alternatingKeyValues))
+	copy(keyvals, alternatingKeyValues)
+	for i := 0; i < len(keyvals); i += 2 {
+		if k, ok := keyvals[i].(string); ok {
+			keyvals[i] = s.keys.Key(".", k)
+		}
+	}
+	_ = s.logger.Log(keyvals...)
+}
+
+// LogFields implements opentracing.Span.
+func (s *loggingSpan) LogFields(fields ...log.Field) {
+	s.Span.LogFields(fields...)
+	keyvals := make([]interface{}, 0, 2*len(fields))
+	for _, field := range fields {
+		keyvals = append(keyvals, s.keys.Key(".", field.Key()), field.Value())
+	}
+	_ = s.logger.Log(keyvals...)
+}
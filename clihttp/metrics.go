@@ -0,0 +1,90 @@
+package clihttp
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// WithMetrics is an option that records the requests to the registerer: the
+// request count, the error count and a latency histogram, labeled by host,
+// method and status code class, such as "2xx". Transport errors are labeled
+// with the class "error", and count as errors along with the 5xx responses.
+//
+// The metrics are recorded by a http.RoundTripper wrapping the transport, so
+// they have no effect if WithDoer is given. Clients sharing a registerer share
+// the metrics.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(client *Client) {
+		client.metrics = newClientMetrics(reg)
+	}
+}
+
+type clientMetrics struct {
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newClientMetrics(reg prometheus.Registerer) *clientMetrics {
+	labels := []string{"host", "method", "code"}
+	m := &clientMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "number of outbound http requests",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_errors_total",
+			Help: "number of outbound http requests failed with an error or a 5xx response",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_client_request_duration_seconds",
+			Help:    "latency of outbound http requests",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+	}
+	m.requests = register(reg, m.requests).(*prometheus.CounterVec)
+	m.errors = register(reg, m.errors).(*prometheus.CounterVec)
+	m.duration = register(reg, m.duration).(*prometheus.HistogramVec)
+	return m
+}
+
+// register registers the collector, or returns the one already registered.
+func register(reg prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(collector); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
+
+// metricsTransport is a http.RoundTripper recording the client metrics.
+type metricsTransport struct {
+	next    http.RoundTripper
+	metrics *clientMetrics
+}
+
+// RoundTrip implements http.RoundTripper.
+func (m *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := m.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	begin := time.Now()
+	response, err := next.RoundTrip(req)
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(response.StatusCode/100) + "xx"
+	}
+	labels := prometheus.Labels{"host": req.URL.Host, "method": req.Method, "code": code}
+	m.metrics.requests.With(labels).Inc()
+	m.metrics.duration.With(labels).Observe(time.Since(begin).Seconds())
+	if err != nil || response.StatusCode >= http.StatusInternalServerError {
+		m.metrics.errors.With(labels).Inc()
+	}
+	return response, err
+}
@@ -0,0 +1,60 @@
+package clihttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Metrics(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/fail" {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	registry := prometheus.NewRegistry()
+	client := NewClient(opentracing.NoopTracer{}, WithMetrics(registry))
+	// clients may share the registry.
+	_ = NewClient(opentracing.NoopTracer{}, WithMetrics(registry))
+	for _, path := range []string{"/", "/", "/fail"} {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			assert.Equal(t, server.Listener.Addr().String(), labels["host"])
+			assert.Equal(t, "GET", labels["method"])
+			name := family.GetName() + "/" + labels["code"]
+			switch {
+			case metric.GetCounter() != nil:
+				values[name] = metric.GetCounter().GetValue()
+			case metric.GetHistogram() != nil:
+				values[name] = float64(metric.GetHistogram().GetSampleCount())
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"http_client_requests_total/2xx":           2,
+		"http_client_requests_total/5xx":           1,
+		"http_client_errors_total/5xx":             1,
+		"http_client_request_duration_seconds/2xx": 2,
+		"http_client_request_duration_seconds/5xx": 1,
+	}, values)
+}
@@ -0,0 +1,62 @@
+package clihttp
+
+import (
+	"net/http"
+	"strings"
+)
+
+var defaultRedactedHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"Proxy-Authorization",
+}
+
+// WithHeaderLogging is an option that logs the request and response headers to
+// the span, with the values of sensitive headers redacted, see
+// WithRedactedHeaders. It is disabled by default, as every header of every
+// request would otherwise end up in the tracing backend.
+func WithHeaderLogging(enable bool) Option {
+	return func(client *Client) {
+		client.logHeaders = enable
+	}
+}
+
+// WithRedactedHeaders is an option that sets the headers whose values are
+// replaced by "***" when the request and response headers are logged to the
+// span, see WithHeaderLogging. Names are matched case-insensitively. If no name is given, the default
+// Authorization, Cookie, Set-Cookie and Proxy-Authorization are redacted, which
+// is also the behavior without this option.
+func WithRedactedHeaders(names ...string) Option {
+	if len(names) == 0 {
+		names = defaultRedactedHeaders
+	}
+	return func(client *Client) {
+		client.redactedHeaders = names
+	}
+}
+
+// redactHeaders formats the headers for logging, with the values of the
+// redacted headers masked.
+func (c *Client) redactHeaders(header http.Header) string {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		if c.isRedacted(name) {
+			redacted[name] = []string{"***"}
+			continue
+		}
+		redacted[name] = values
+	}
+	var sb strings.Builder
+	_ = redacted.Write(&sb)
+	return sb.String()
+}
+
+func (c *Client) isRedacted(name string) bool {
+	for _, redacted := range c.redactedHeaders {
+		if strings.EqualFold(name, redacted) {
+			return true
+		}
+	}
+	return false
+}
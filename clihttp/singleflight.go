@@ -0,0 +1,100 @@
+package clihttp
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+var defaultSingleFlightHeaders = []string{
+	"Accept",
+	"Accept-Encoding",
+	"Accept-Language",
+	"Authorization",
+	"Cookie",
+}
+
+// WithSingleFlight is an option that coalesces concurrent identical GET and HEAD
+// requests into a single upstream call. Requests are identical if they share
+// the method, the URL and the values of the given headers. If no header is
+// given, Accept, Accept-Encoding, Accept-Language, Authorization and Cookie are
+// compared. Requests with a body are never coalesced.
+//
+// The response of the shared call is read into memory, and every caller
+// receives its own copy of the response with a fresh body reader over the
+// buffered bytes and a cloned header, so callers can read and close their body
+// independently. Streaming responses therefore shouldn't go through a client
+// with this option.
+//
+// The shared call runs with the context of the first caller. If that context is
+// canceled, all callers waiting for the call receive the error.
+func WithSingleFlight(headers ...string) Option {
+	if len(headers) == 0 {
+		headers = defaultSingleFlightHeaders
+	}
+	return func(client *Client) {
+		client.singleFlight = &singleFlight{headers: headers}
+	}
+}
+
+type singleFlight struct {
+	group   singleflight.Group
+	headers []string
+}
+
+type bufferedResponse struct {
+	response *http.Response
+	body     []byte
+}
+
+func (s *singleFlight) eligible(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody
+}
+
+func (s *singleFlight) key(req *http.Request) string {
+	var builder strings.Builder
+	builder.WriteString(req.Method)
+	builder.WriteString(" ")
+	builder.WriteString(req.URL.String())
+	for _, header := range s.headers {
+		builder.WriteString("\n")
+		builder.WriteString(header)
+		builder.WriteString(": ")
+		builder.WriteString(strings.Join(req.Header.Values(header), ", "))
+	}
+	return builder.String()
+}
+
+// do sends the request through the underlying doer, sharing the response with
+// other callers of identical requests. It reports whether the response was
+// shared.
+func (s *singleFlight) do(doer HttpDoer, req *http.Request) (*http.Response, bool, error) {
+	v, err, shared := s.group.Do(s.key(req), func() (interface{}, error) {
+		response, err := doer.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		byt, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot read response body")
+		}
+		return bufferedResponse{response: response, body: byt}, nil
+	})
+	if err != nil {
+		return nil, shared, err
+	}
+	buffered := v.(bufferedResponse)
+	response := *buffered.response
+	response.Header = buffered.response.Header.Clone()
+	response.Body = ioutil.NopCloser(bytes.NewReader(buffered.body))
+	response.Request = req
+	return &response, shared, nil
+}
@@ -0,0 +1,59 @@
+package clihttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestClient_SingleFlight(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		hits.Inc()
+		time.Sleep(100 * time.Millisecond)
+		writer.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	client := NewClient(opentracing.NoopTracer{}, WithSingleFlight())
+
+	cases := []struct {
+		name     string
+		method   string
+		header   func(i int) string
+		expected int32
+	}{
+		{"identical", http.MethodGet, func(i int) string { return "foo" }, 1},
+		{"different headers", http.MethodGet, func(i int) string { return strings.Repeat("a", i+1) }, 10},
+		{"not idempotent", http.MethodPost, func(i int) string { return "foo" }, 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			hits.Store(0)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					req, _ := http.NewRequest(c.method, server.URL, nil)
+					req.Header.Set("Authorization", c.header(i))
+					resp, err := client.Do(req)
+					assert.NoError(t, err)
+					defer resp.Body.Close()
+					byt, _ := ioutil.ReadAll(resp.Body)
+					assert.Equal(t, "hello", string(byt))
+				}(i)
+			}
+			wg.Wait()
+			assert.Equal(t, c.expected, hits.Load())
+		})
+	}
+}
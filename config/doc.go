@@ -45,6 +45,10 @@
 //
 //  go run main.go config init -o ./config/config.yaml
 //
+// Another command prints the configuration actually in use, with secrets redacted:
+//
+//  go run main.go config dump --style json
+//
 // Best Practice
 //
 // In general you should not pass contract.ConfigAccessor or config.KoanfAdapter to your services. You should only
@@ -8,11 +8,15 @@ import (
 	"github.com/DoNewsCode/core/di"
 	"gopkg.in/yaml.v3"
 
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
 
 	"github.com/DoNewsCode/core/contract"
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
 	"github.com/oklog/run"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -135,15 +139,100 @@ func (m Module) ProvideCommand(command *cobra.Command) {
 		"yaml",
 		"The output file style",
 	)
+	var dumpStyle string
+	dumpCmd := &cobra.Command{
+		Use:   "dump",
+		Short: "print the resolved config.",
+		Long:  "print the configuration currently in use, which is the default config of installed modules merged with the loaded config. Values of keys named like password, secret or token are redacted.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return m.dump(cmd.OutOrStdout(), dumpStyle)
+		},
+	}
+	dumpCmd.Flags().StringVarP(
+		&dumpStyle,
+		"style",
+		"s",
+		"yaml",
+		"The output style, either yaml or json",
+	)
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "manage configuration",
 		Long:  "manage configuration, such as export a copy of default config.",
 	}
 	configCmd.AddCommand(initCmd)
+	configCmd.AddCommand(dumpCmd)
 	command.AddCommand(configCmd)
 }
 
+// dump writes the resolved configuration to the writer.
+func (m Module) dump(writer io.Writer, style string) error {
+	k := koanf.New(".")
+	for _, exportedConfig := range m.exportedConfigs {
+		// Normalize the exported data, which may contain structs, into plain maps.
+		bytes, err := yaml.Marshal(exportedConfig.Data)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal exported config")
+		}
+		var data map[string]interface{}
+		if err := yaml.Unmarshal(bytes, &data); err != nil {
+			return errors.Wrap(err, "failed to unmarshal exported config")
+		}
+		if err := k.Load(confmap.Provider(data, ""), nil); err != nil {
+			return errors.Wrap(err, "failed to load exported config")
+		}
+	}
+	m.conf.rwlock.RLock()
+	err := k.Load(confmap.Provider(m.conf.K.Raw(), ""), nil)
+	m.conf.rwlock.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to load config")
+	}
+
+	resolved := redact(k.Raw())
+	switch style {
+	case "json":
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(resolved)
+	case "yaml":
+		encoder := yaml.NewEncoder(writer)
+		defer encoder.Close()
+		return encoder.Encode(resolved)
+	default:
+		return fmt.Errorf("unsupported config style %s", style)
+	}
+}
+
+var sensitiveKey = regexp.MustCompile(`(?i)password|secret|token`)
+
+// redact replaces the values of sensitive keys in place, including those of
+// the maps nested in lists.
+func redact(data map[string]interface{}) map[string]interface{} {
+	for k, v := range data {
+		if sensitiveKey.MatchString(k) {
+			data[k] = "******"
+			continue
+		}
+		data[k] = redactValue(v)
+	}
+	return data
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return redact(v)
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
 func getHandler(style string) (handler, error) {
 	switch style {
 	case "json":
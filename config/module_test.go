@@ -151,3 +151,45 @@ type MockWatcher struct{}
 func (m *MockWatcher) Watch(ctx context.Context, reload func() error) error {
 	return reload()
 }
+
+func TestModule_DumpCommand(t *testing.T) {
+	conf, _ := NewConfig(WithProviderLayer(confmap.Provider(map[string]interface{}{
+		"foo":            "override",
+		"db.password":    "hunter2",
+		"db.accessToken": "abc",
+		"etcd.endpoints": []interface{}{
+			map[string]interface{}{"host": "etcd-0", "password": "s3cr3t"},
+			[]interface{}{map[string]interface{}{"secret": "n3sted"}},
+		},
+	}, "."), nil))
+	mod := Module{conf: conf, exportedConfigs: []ExportedConfig{
+		{
+			"foo",
+			map[string]interface{}{
+				"foo": "bar",
+				"baz": "qux",
+				"db":  map[string]interface{}{"user": "root"},
+			},
+			"A mock config",
+		},
+	}}
+	rootCmd := &cobra.Command{
+		Use: "root",
+	}
+	mod.ProvideCommand(rootCmd)
+
+	for _, style := range []string{"yaml", "json"} {
+		var out strings.Builder
+		rootCmd.SetOut(&out)
+		rootCmd.SetArgs([]string{"config", "dump", "--style", style})
+		assert.NoError(t, rootCmd.Execute())
+		assert.Contains(t, out.String(), "override")
+		assert.Contains(t, out.String(), "qux")
+		assert.Contains(t, out.String(), "root")
+		assert.NotContains(t, out.String(), "hunter2")
+		assert.NotContains(t, out.String(), "abc")
+		assert.Contains(t, out.String(), "etcd-0")
+		assert.NotContains(t, out.String(), "s3cr3t")
+		assert.NotContains(t, out.String(), "n3sted")
+	}
+}
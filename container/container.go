@@ -4,11 +4,20 @@ Package container includes the Container type, witch contains a collection of mo
 package container
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/DoNewsCode/core/contract"
 	"github.com/Reasno/ifilter"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-multierror"
 	"github.com/oklog/run"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
@@ -42,27 +51,142 @@ type CloserProvider interface {
 	ProvideCloser()
 }
 
+// ErrCloserProvider provides a shutdown function that reports its failure. The
+// errors are collected by Container.ShutdownE. If a module implements both
+// ErrCloserProvider and CloserProvider, only ProvideCloserE is called.
+type ErrCloserProvider interface {
+	ProvideCloserE() error
+}
+
+// CloserPriority can be implemented by a CloserProvider or an ErrCloserProvider
+// to be closed earlier or
+// later by Container.ShutdownOrdered. Closers with a higher priority are closed
+// first. The priority of other closers is zero.
+type CloserPriority interface {
+	CloserPriority() int
+}
+
 // RunProvider provides a runnable actor. Use it to register any server-like
 // actions. For example, kafka consumer can be started here.
 type RunProvider interface {
 	ProvideRunGroup(group *run.Group)
 }
 
-// Container holds all modules registered.
+// NamespaceAware is implemented by modules that can be registered more than once,
+// each instance reading its configuration under a different namespace. The
+// namespace is handed over by AddNamespacedModule before the module is
+// registered, so SetNamespace must be implemented on a pointer receiver for the
+// change to stick.
+//
+// Modules should scope every configuration lookup with NamespacedKey, or, if
+// the contract.ConfigAccessor also implements contract.ConfigRouter, route to
+// the namespace directly:
+//
+//	func (m *Module) SetNamespace(ns string) {
+//		m.ns = ns
+//	}
+//
+//	func (m *Module) ProvideHTTP(router *mux.Router) {
+//		addr := m.conf.String(container.NamespacedKey(m.ns, "http.addr"))
+//		// ...
+//	}
+type NamespaceAware interface {
+	SetNamespace(ns string)
+}
+
+// NamespacedKey prefixes the configuration key with the namespace. An empty
+// namespace leaves the key untouched, so modules registered without a
+// namespace keep reading the fixed keys.
+func NamespacedKey(ns, key string) string {
+	if ns == "" {
+		return key
+	}
+	return strings.Join([]string{ns, key}, ".")
+}
+
+// ProviderPanic is the panic value rethrown when a provider method panics in a
+// Container created with WithPanicRecovery. It names the offending module, and
+// implements error so that it can be returned by whoever recovers it.
+type ProviderPanic struct {
+	// Module is the type name of the module, for example *foo.Module.
+	Module string
+	// Provider is the name of the provider method, for example ProvideHTTP.
+	Provider string
+	// Value is the original panic value.
+	Value interface{}
+	// Stack is the stack trace at the original panic.
+	Stack []byte
+}
+
+// Error implements error.
+func (p *ProviderPanic) Error() string {
+	return fmt.Sprintf("module %s panicked in %s: %v", p.Module, p.Provider, p.Value)
+}
+
+// Unwrap returns the original panic value if it is an error.
+func (p *ProviderPanic) Unwrap() error {
+	if err, ok := p.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// Option configures the Container.
+type Option func(*Container)
+
+// WithPanicRecovery is an option that recovers panics in the provider methods of
+// modules, such as ProvideHTTP or ProvideGRPC, and panics again with a
+// *ProviderPanic naming the module. Without this option, panics propagate
+// untouched.
+func WithPanicRecovery() Option {
+	return func(container *Container) {
+		container.recoverPanics = true
+	}
+}
+
+// WithLogger is an option that sets the logger of the Container. It is used to
+// report the closers still running when ShutdownContext gives up.
+func WithLogger(logger log.Logger) Option {
+	return func(container *Container) {
+		container.logger = logger
+	}
+}
+
+// New creates a Container. The zero value of Container is also ready to use.
+func New(opts ...Option) *Container {
+	c := &Container{}
+	for _, f := range opts {
+		f(c)
+	}
+	return c
+}
+
+// Container holds all modules registered. Modules can be added concurrently.
+// The Apply methods see the modules registered by the time they are called.
 type Container struct {
+	mu               sync.RWMutex
+	lifecycle        lifecycle
+	recoverPanics    bool
+	logger           log.Logger
 	httpProviders    []func(router *mux.Router)
 	grpcProviders    []func(server *grpc.Server)
-	closerProviders  []func()
+	closerProviders  []closer
 	runProviders     []func(g *run.Group)
 	modules          ifilter.Collection
 	cronProviders    []func(crontab *cron.Cron)
 	commandProviders []func(command *cobra.Command)
+	wsProviders      []func(hub *Hub)
+	healthChecks     []HealthCheck
+	registered       []interface{}
 }
 
 // ApplyRouter iterates through every HTTPProvider registered in the container,
 // and introduce the router to everyone.
 func (c *Container) ApplyRouter(router *mux.Router) {
-	for _, p := range c.httpProviders {
+	c.mu.RLock()
+	providers := c.httpProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
 		p(router)
 	}
 }
@@ -70,30 +194,145 @@ func (c *Container) ApplyRouter(router *mux.Router) {
 // ApplyGRPCServer iterates through every GRPCProvider registered in the container,
 // and introduce a *grpc.Server to everyone.
 func (c *Container) ApplyGRPCServer(server *grpc.Server) {
-	for _, p := range c.grpcProviders {
+	c.mu.RLock()
+	providers := c.grpcProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
 		p(server)
 	}
 }
 
 // Shutdown iterates through every CloserProvider registered in the container,
-// and calls them in parallel.
+// and calls them in parallel. The Container is Draining while the closers run,
+// and Stopped once they have all returned. Errors of ErrCloserProvider are
+// discarded, use ShutdownE to get them.
+//
+// Shutdown is the fastest way to tear down independent modules. If a module
+// must be closed before another one, for example a queue flushed before its
+// database is closed, use ShutdownOrdered instead.
 func (c *Container) Shutdown() {
-	var wg sync.WaitGroup
-	for _, p := range c.closerProviders {
+	_ = c.ShutdownE()
+}
+
+// ShutdownE is like Shutdown, but returns the errors of every failed
+// ErrCloserProvider aggregated in a *multierror.Error.
+func (c *Container) ShutdownE() error {
+	_ = c.Advance(Draining)
+	defer c.Advance(Stopped)
+
+	c.mu.RLock()
+	closers := c.closerProviders
+	c.mu.RUnlock()
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result error
+	)
+	for _, p := range closers {
 		wg.Add(1)
 		p := p
 		go func() {
-			p()
-			wg.Done()
+			defer wg.Done()
+			if err := p.close(); err != nil {
+				mu.Lock()
+				result = multierror.Append(result, err)
+				mu.Unlock()
+			}
 		}()
 	}
 	wg.Wait()
+	return result
+}
+
+// ShutdownContext is like Shutdown, but returns as soon as the context is done,
+// so that a hung closer can't block a shutdown bounded by a grace period. It
+// returns the sorted names of the closers still running at that point, and
+// also logs them if a logger is set with WithLogger. They are not interrupted,
+// and keep running in the background. The Container stays Draining until they
+// all return.
+//
+// Like Shutdown, it calls all the closers concurrently, regardless of their
+// CloserPriority. Use ShutdownOrdered when the order matters.
+func (c *Container) ShutdownContext(ctx context.Context) []string {
+	_ = c.Advance(Draining)
+
+	c.mu.RLock()
+	closers := c.closerProviders
+	c.mu.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running = make(map[int]string, len(closers))
+		done    = make(chan struct{})
+	)
+	for i, p := range closers {
+		running[i] = p.name
+	}
+	for i, p := range closers {
+		wg.Add(1)
+		go func(i int, p closer) {
+			defer wg.Done()
+			_ = p.close()
+			mu.Lock()
+			delete(running, i)
+			mu.Unlock()
+		}(i, p)
+	}
+	go func() {
+		wg.Wait()
+		_ = c.Advance(Stopped)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+	mu.Lock()
+	names := make([]string, 0, len(running))
+	for _, name := range running {
+		names = append(names, name)
+	}
+	mu.Unlock()
+	sort.Strings(names)
+	if c.logger != nil {
+		_ = level.Warn(c.logger).Log("msg", "shutdown deadline exceeded, closers still running", "closers", strings.Join(names, ", "))
+	}
+	return names
+}
+
+// ShutdownOrdered is like Shutdown, but calls the closers one by one. Closers
+// are called by descending CloserPriority, and then in the reverse order of
+// registration, so that a module is closed before the modules registered ahead
+// of it, which it may depend on.
+func (c *Container) ShutdownOrdered() {
+	_ = c.Advance(Draining)
+	defer c.Advance(Stopped)
+
+	c.mu.RLock()
+	closers := make([]closer, len(c.closerProviders))
+	for i := range c.closerProviders {
+		closers[len(closers)-1-i] = c.closerProviders[i]
+	}
+	c.mu.RUnlock()
+	sort.SliceStable(closers, func(i, j int) bool {
+		return closers[i].priority > closers[j].priority
+	})
+	for _, p := range closers {
+		_ = p.close()
+	}
 }
 
 // ApplyRunGroup iterates through every RunProvider registered in the container,
 // and introduce the *run.Group to everyone.
 func (c *Container) ApplyRunGroup(g *run.Group) {
-	for _, p := range c.runProviders {
+	c.mu.RLock()
+	providers := c.runProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
 		p(g)
 	}
 }
@@ -114,13 +353,18 @@ func (c *Container) ApplyRunGroup(g *run.Group) {
 	})
 */
 func (c *Container) Modules() ifilter.Collection {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.modules
 }
 
 // ApplyCron iterates through every CronProvider registered in the container,
 // and introduce the *cron.Cron to everyone.
 func (c *Container) ApplyCron(crontab *cron.Cron) {
-	for _, p := range c.cronProviders {
+	c.mu.RLock()
+	providers := c.cronProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
 		p(crontab)
 	}
 }
@@ -128,33 +372,242 @@ func (c *Container) ApplyCron(crontab *cron.Cron) {
 // ApplyRootCommand iterates through every CommandProvider registered in the container,
 // and introduce the root *cobra.Command to everyone.
 func (c *Container) ApplyRootCommand(command *cobra.Command) {
-	for _, p := range c.commandProviders {
+	c.mu.RLock()
+	providers := c.commandProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
 		p(command)
 	}
 }
 
+// ApplyWebsocket iterates through every WebsocketProvider registered in the
+// container, and introduce the *Hub to everyone.
+func (c *Container) ApplyWebsocket(hub *Hub) {
+	c.mu.RLock()
+	providers := c.wsProviders
+	c.mu.RUnlock()
+	for _, p := range providers {
+		p(hub)
+	}
+}
+
+// AddNamespacedModule registers the module under a configuration namespace. If
+// the module implements NamespaceAware, the namespace is passed to it before
+// registration. Otherwise, it is identical to AddModule.
+func (c *Container) AddNamespacedModule(ns string, module interface{}) {
+	if p, ok := module.(NamespaceAware); ok {
+		p.SetNamespace(ns)
+	}
+	c.AddModule(module)
+}
+
+// AddModule registers the module. Every provider interface the module
+// implements is collected, so that it can be later applied.
 func (c *Container) AddModule(module interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.registered = append(c.registered, module)
+	c.register(module)
+}
+
+// Remove deregisters a module previously added, and reports whether it was
+// found. Modules are compared by identity: pointers by address, and other values
+// by equality. If the module was added more than once, only the last
+// registration is removed.
+//
+// Function modules can't be removed, and Remove returns false for them. Go can
+// only compare functions by code pointer, so different closures made from the
+// same literal, such as func() { conn.Close() } added in a loop, would be
+// mistaken for one another. Add a pointer to a type implementing the provider
+// interfaces instead if the module needs to be removed.
+func (c *Container) Remove(module interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := len(c.registered) - 1; i >= 0; i-- {
+		if !sameModule(c.registered[i], module) {
+			continue
+		}
+		registered := make([]interface{}, 0, len(c.registered)-1)
+		registered = append(registered, c.registered[:i]...)
+		registered = append(registered, c.registered[i+1:]...)
+		c.reset()
+		c.registered = registered
+		for _, m := range registered {
+			c.register(m)
+		}
+		return true
+	}
+	return false
+}
+
+// Reset deregisters every module. The lifecycle state is not affected.
+func (c *Container) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+	c.registered = nil
+}
+
+// reset clears the providers. The slices are replaced rather than truncated, so
+// that the Apply methods iterating over the old ones are not disturbed.
+func (c *Container) reset() {
+	c.httpProviders = nil
+	c.grpcProviders = nil
+	c.closerProviders = nil
+	c.runProviders = nil
+	c.modules = nil
+	c.cronProviders = nil
+	c.commandProviders = nil
+	c.wsProviders = nil
+	c.healthChecks = nil
+}
+
+// sameModule compares modules by identity. Functions never match, see Remove.
+func sameModule(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	switch va.Kind() {
+	case reflect.Func:
+		// Closures of the same literal share the code pointer.
+		return false
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.UnsafePointer:
+		return va.Pointer() == vb.Pointer()
+	}
+	if !va.Type().Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// register collects the providers of the module. c.mu must be held.
+func (c *Container) register(module interface{}) {
 	if p, ok := module.(func()); ok {
-		c.closerProviders = append(c.closerProviders, p)
+		c.closerProviders = append(c.closerProviders, closer{name: fmt.Sprintf("%T", module), close: c.guardCloser(module, "closer", p)})
 		return
 	}
 	if p, ok := module.(HTTPProvider); ok {
-		c.httpProviders = append(c.httpProviders, p.ProvideHTTP)
+		provide := p.ProvideHTTP
+		if c.recoverPanics {
+			provide = func(router *mux.Router) {
+				defer rethrow(module, "ProvideHTTP")
+				p.ProvideHTTP(router)
+			}
+		}
+		c.httpProviders = append(c.httpProviders, provide)
 	}
 	if p, ok := module.(GRPCProvider); ok {
-		c.grpcProviders = append(c.grpcProviders, p.ProvideGRPC)
+		provide := p.ProvideGRPC
+		if c.recoverPanics {
+			provide = func(server *grpc.Server) {
+				defer rethrow(module, "ProvideGRPC")
+				p.ProvideGRPC(server)
+			}
+		}
+		c.grpcProviders = append(c.grpcProviders, provide)
 	}
 	if p, ok := module.(CronProvider); ok {
-		c.cronProviders = append(c.cronProviders, p.ProvideCron)
+		provide := p.ProvideCron
+		if c.recoverPanics {
+			provide = func(crontab *cron.Cron) {
+				defer rethrow(module, "ProvideCron")
+				p.ProvideCron(crontab)
+			}
+		}
+		c.cronProviders = append(c.cronProviders, provide)
 	}
 	if p, ok := module.(RunProvider); ok {
-		c.runProviders = append(c.runProviders, p.ProvideRunGroup)
+		provide := p.ProvideRunGroup
+		if c.recoverPanics {
+			provide = func(group *run.Group) {
+				defer rethrow(module, "ProvideRunGroup")
+				p.ProvideRunGroup(group)
+			}
+		}
+		c.runProviders = append(c.runProviders, provide)
 	}
 	if p, ok := module.(CommandProvider); ok {
-		c.commandProviders = append(c.commandProviders, p.ProvideCommand)
+		provide := p.ProvideCommand
+		if c.recoverPanics {
+			provide = func(command *cobra.Command) {
+				defer rethrow(module, "ProvideCommand")
+				p.ProvideCommand(command)
+			}
+		}
+		c.commandProviders = append(c.commandProviders, provide)
 	}
-	if p, ok := module.(CloserProvider); ok {
-		c.closerProviders = append(c.closerProviders, p.ProvideCloser)
+	if p, ok := module.(WebsocketProvider); ok {
+		provide := p.ProvideWebsocket
+		if c.recoverPanics {
+			provide = func(hub *Hub) {
+				defer rethrow(module, "ProvideWebsocket")
+				p.ProvideWebsocket(hub)
+			}
+		}
+		c.wsProviders = append(c.wsProviders, provide)
+	}
+	if p, ok := module.(HealthCheckProvider); ok {
+		name, check := c.provideHealthCheck(module, p)
+		c.healthChecks = append(c.healthChecks, HealthCheck{Name: name, Check: check})
+	}
+	if p, ok := module.(ErrCloserProvider); ok {
+		c.addCloser(module, closer{close: c.guardErrCloser(module, "ProvideCloserE", p.ProvideCloserE)})
+	} else if p, ok := module.(CloserProvider); ok {
+		c.addCloser(module, closer{close: c.guardCloser(module, "ProvideCloser", p.ProvideCloser)})
 	}
 	c.modules = append(c.modules, module)
 }
+
+// closer is a registered closer with its priority.
+type closer struct {
+	name     string
+	close    func() error
+	priority int
+}
+
+func (c *Container) addCloser(module interface{}, cl closer) {
+	cl.name = fmt.Sprintf("%T", module)
+	if p, ok := module.(CloserPriority); ok {
+		cl.priority = p.CloserPriority()
+	}
+	c.closerProviders = append(c.closerProviders, cl)
+}
+
+func (c *Container) guardCloser(module interface{}, provider string, closer func()) func() error {
+	return c.guardErrCloser(module, provider, func() error {
+		closer()
+		return nil
+	})
+}
+
+func (c *Container) guardErrCloser(module interface{}, provider string, closer func() error) func() error {
+	if !c.recoverPanics {
+		return closer
+	}
+	return func() error {
+		defer rethrow(module, provider)
+		return closer()
+	}
+}
+
+// rethrow must be deferred directly, so that recover works.
+func rethrow(module interface{}, provider string) {
+	if r := recover(); r != nil {
+		if p, ok := r.(*ProviderPanic); ok {
+			panic(p)
+		}
+		panic(&ProviderPanic{
+			Module:   fmt.Sprintf("%T", module),
+			Provider: provider,
+			Value:    r,
+			Stack:    debug.Stack(),
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package container
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/go-kit/kit/log"
 	"github.com/gorilla/mux"
+	"github.com/hashicorp/go-multierror"
 	"github.com/oklog/run"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
@@ -37,26 +44,26 @@ func TestContainer_AddModule(t *testing.T) {
 	cases := []struct {
 		name    string
 		module  interface{}
-		asserts func(t *testing.T, container Container)
+		asserts func(t *testing.T, container *Container)
 	}{
 		{
 			"any",
 			"foo",
-			func(t *testing.T, container Container) {
+			func(t *testing.T, container *Container) {
 				assert.Contains(t, container.modules, "foo")
 			},
 		},
 		{
 			"close",
 			func() {},
-			func(t *testing.T, container Container) {
+			func(t *testing.T, container *Container) {
 				assert.Len(t, container.closerProviders, 1)
 			},
 		},
 		{
 			"mock",
 			mock{},
-			func(t *testing.T, container Container) {
+			func(t *testing.T, container *Container) {
 				assert.Len(t, container.runProviders, 1)
 				assert.Len(t, container.httpProviders, 1)
 				assert.Len(t, container.grpcProviders, 1)
@@ -73,7 +80,243 @@ func TestContainer_AddModule(t *testing.T) {
 			t.Parallel()
 			var container Container
 			container.AddModule(c.module)
-			c.asserts(t, container)
+			c.asserts(t, &container)
 		})
 	}
 }
+
+type namespaced struct {
+	ns string
+}
+
+func (n *namespaced) SetNamespace(ns string) {
+	n.ns = ns
+}
+
+func TestContainer_AddNamespacedModule(t *testing.T) {
+	var container Container
+	foo, bar := &namespaced{}, &namespaced{}
+	container.AddNamespacedModule("foo", foo)
+	container.AddNamespacedModule("bar", bar)
+	assert.Equal(t, "foo", foo.ns)
+	assert.Equal(t, "bar", bar.ns)
+	assert.Len(t, container.modules, 2)
+
+	container.AddNamespacedModule("baz", "not aware")
+	assert.Contains(t, container.modules, "not aware")
+}
+
+func TestNamespacedKey(t *testing.T) {
+	assert.Equal(t, "http.addr", NamespacedKey("", "http.addr"))
+	assert.Equal(t, "admin.http.addr", NamespacedKey("admin", "http.addr"))
+}
+
+func TestContainer_WithPanicRecovery(t *testing.T) {
+	t.Run("recover", func(t *testing.T) {
+		container := New(WithPanicRecovery())
+		container.AddModule(mock{})
+		defer func() {
+			r := recover()
+			p, ok := r.(*ProviderPanic)
+			assert.True(t, ok)
+			assert.Equal(t, "container.mock", p.Module)
+			assert.Equal(t, "ProvideHTTP", p.Provider)
+			assert.Equal(t, "implement me", p.Value)
+			assert.Contains(t, p.Error(), "container.mock")
+		}()
+		container.ApplyRouter(mux.NewRouter())
+	})
+	t.Run("fail fast", func(t *testing.T) {
+		container := New()
+		container.AddModule(mock{})
+		defer func() {
+			assert.Equal(t, "implement me", recover())
+		}()
+		container.ApplyRouter(mux.NewRouter())
+	})
+}
+
+func TestContainer_State(t *testing.T) {
+	var c Container
+	assert.Equal(t, Registering, c.State())
+	transitions := c.Transitions()
+
+	assert.NoError(t, c.Advance(Built))
+	assert.NoError(t, c.Advance(Running))
+	assert.Error(t, c.Advance(Starting))
+	assert.Error(t, c.Advance(Running))
+	assert.Equal(t, Running, c.State())
+
+	c.Shutdown()
+	assert.Equal(t, Stopped, c.State())
+
+	var got []Transition
+	for transition := range transitions {
+		got = append(got, transition)
+	}
+	assert.Equal(t, []Transition{
+		{Registering, Built},
+		{Built, Running},
+		{Running, Draining},
+		{Draining, Stopped},
+	}, got)
+
+	_, ok := <-c.Transitions()
+	assert.False(t, ok)
+	assert.Equal(t, "Draining", Draining.String())
+}
+
+type orderedCloser struct {
+	name     string
+	priority int
+	closed   *[]string
+}
+
+func (o orderedCloser) ProvideCloser() {
+	*o.closed = append(*o.closed, o.name)
+}
+
+func (o orderedCloser) CloserPriority() int {
+	return o.priority
+}
+
+func TestContainer_ShutdownOrdered(t *testing.T) {
+	var (
+		c      Container
+		closed []string
+	)
+	c.AddModule(orderedCloser{name: "db", closed: &closed})
+	c.AddModule(func() { closed = append(closed, "func") })
+	c.AddModule(orderedCloser{name: "queue", closed: &closed})
+	c.AddModule(orderedCloser{name: "server", priority: 10, closed: &closed})
+
+	c.ShutdownOrdered()
+	assert.Equal(t, []string{"server", "queue", "func", "db"}, closed)
+	assert.Equal(t, Stopped, c.State())
+}
+
+type errCloser struct {
+	err error
+}
+
+func (e errCloser) ProvideCloserE() error {
+	return e.err
+}
+
+func (e errCloser) ProvideCloser() {
+	panic("ProvideCloser should not be called")
+}
+
+func TestContainer_ShutdownE(t *testing.T) {
+	var c Container
+	c.AddModule(errCloser{err: errors.New("foo")})
+	c.AddModule(errCloser{err: errors.New("bar")})
+	c.AddModule(errCloser{})
+	c.AddModule(func() {})
+
+	err := c.ShutdownE()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "foo")
+	assert.Contains(t, err.Error(), "bar")
+	assert.Len(t, err.(*multierror.Error).Errors, 2)
+
+	var ok Container
+	ok.AddModule(errCloser{})
+	assert.NoError(t, ok.ShutdownE())
+}
+
+type commandModule struct{}
+
+func (c commandModule) ProvideCommand(command *cobra.Command) {}
+
+func TestContainer_AddModuleConcurrently(t *testing.T) {
+	var (
+		c  Container
+		wg sync.WaitGroup
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			c.AddModule(commandModule{})
+			c.AddModule(func() {})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = c.Modules()
+			c.ApplyRootCommand(nil)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, c.Modules(), 50)
+	assert.Len(t, c.closerProviders, 50)
+}
+
+func TestContainer_Remove(t *testing.T) {
+	var (
+		c      Container
+		closed []string
+	)
+	db := &orderedCloser{name: "db", closed: &closed}
+	closeFunc := func() { closed = append(closed, "func") }
+	c.AddModule(db)
+	c.AddModule(closeFunc)
+	c.AddModule(commandModule{})
+	c.AddModule("foo")
+
+	assert.False(t, c.Remove(&orderedCloser{name: "db", closed: &closed}))
+	assert.True(t, c.Remove(db))
+	assert.False(t, c.Remove(db))
+	assert.True(t, c.Remove("foo"))
+	assert.Len(t, c.Modules(), 1)
+	assert.Len(t, c.commandProviders, 1)
+
+	c.ShutdownOrdered()
+	assert.Equal(t, []string{"func"}, closed)
+
+	// Functions are never removed, as closures can't be told apart.
+	assert.False(t, c.Remove(closeFunc))
+	assert.Len(t, c.closerProviders, 1)
+
+	c.Reset()
+	assert.Empty(t, c.Modules())
+	assert.Empty(t, c.commandProviders)
+}
+
+func TestContainer_ShutdownContext(t *testing.T) {
+	var (
+		buf     bytes.Buffer
+		release = make(chan struct{})
+	)
+	c := New(WithLogger(log.NewLogfmtLogger(&buf)))
+	c.AddModule(func() {})
+	c.AddModule(errCloser{})
+	c.AddModule(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, []string{"func()"}, c.ShutdownContext(ctx))
+	assert.Contains(t, buf.String(), "level=warn")
+	assert.Contains(t, buf.String(), "closers=func()")
+	assert.NotContains(t, buf.String(), "errCloser")
+	assert.Equal(t, Draining, c.State())
+
+	transitions := c.Transitions()
+	close(release)
+	<-transitions
+	assert.Equal(t, Stopped, c.State())
+
+	c = New()
+	c.AddModule(func() {})
+	assert.Empty(t, c.ShutdownContext(context.Background()))
+	assert.Equal(t, Stopped, c.State())
+
+	// Without a logger, the running closers are still returned.
+	release = make(chan struct{})
+	defer close(release)
+	c = New()
+	c.AddModule(func() { <-release })
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, []string{"func()"}, c.ShutdownContext(ctx))
+}
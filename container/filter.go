@@ -0,0 +1,35 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Filter collects every module in the container that is assignable to the
+// element type of the slice target points to. The element type can be an
+// interface, such as a MigrationProvider, or a concrete module type. It is the
+// one-liner alternative to Modules().Filter for the common lookups:
+//
+//	var providers []MigrationProvider
+//	if err := container.Filter(c, &providers); err != nil {
+//		return err
+//	}
+//
+// Matching modules are appended to the slice in the order of registration. An
+// error is returned if target is not a non-nil pointer to a slice.
+func Filter(c *Container, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("container: Filter target must be a non-nil pointer to a slice, got %T", target)
+	}
+	slice := value.Elem()
+	elemType := slice.Type().Elem()
+	for _, module := range c.Modules() {
+		if module == nil || !reflect.TypeOf(module).AssignableTo(elemType) {
+			continue
+		}
+		slice = reflect.Append(slice, reflect.ValueOf(module))
+	}
+	value.Elem().Set(slice)
+	return nil
+}
@@ -0,0 +1,25 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	var c Container
+	c.AddModule(healthModule{name: "db"})
+	c.AddModule(commandModule{})
+	c.AddModule(healthModule{name: "cache"})
+
+	var providers []HealthCheckProvider
+	assert.NoError(t, Filter(&c, &providers))
+	assert.Equal(t, []HealthCheckProvider{healthModule{name: "db"}, healthModule{name: "cache"}}, providers)
+
+	var modules []commandModule
+	assert.NoError(t, Filter(&c, &modules))
+	assert.Len(t, modules, 1)
+
+	assert.Error(t, Filter(&c, providers))
+	assert.Error(t, Filter(&c, (*[]HealthCheckProvider)(nil)))
+}
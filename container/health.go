@@ -0,0 +1,87 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// HealthCheckProvider provides a named health check, for example a ping to the
+// database a module depends on. The check should respect the deadline of the
+// context.
+type HealthCheckProvider interface {
+	ProvideHealthCheck() (name string, check func(ctx context.Context) error)
+}
+
+// HealthCheck is a health check provided by a HealthCheckProvider.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthChecks returns the health checks of every HealthCheckProvider registered
+// in the container, in the order of registration.
+func (c *Container) HealthChecks() []HealthCheck {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	checks := make([]HealthCheck, len(c.healthChecks))
+	copy(checks, c.healthChecks)
+	return checks
+}
+
+func (c *Container) provideHealthCheck(module interface{}, p HealthCheckProvider) (string, func(ctx context.Context) error) {
+	if c.recoverPanics {
+		defer rethrow(module, "ProvideHealthCheck")
+	}
+	return p.ProvideHealthCheck()
+}
+
+// RunHealthChecks runs the checks concurrently, and returns the error of every
+// check by name. A nil error means the check has passed.
+func RunHealthChecks(ctx context.Context, checks []HealthCheck) map[string]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(checks))
+	)
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			err := check.Check(ctx)
+			mu.Lock()
+			results[check.Name] = err
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+	return results
+}
+
+// HealthCheckHandler returns a http.Handler that runs the checks concurrently
+// on every request, and reports the results as JSON. The status code is 200 if
+// every check passes, otherwise 503.
+//
+//	router.Handle("/health", container.HealthCheckHandler(c.HealthChecks()))
+func HealthCheckHandler(checks []HealthCheck) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		report := struct {
+			Status string            `json:"status"`
+			Checks map[string]string `json:"checks"`
+		}{Status: "ok", Checks: make(map[string]string, len(checks))}
+		code := http.StatusOK
+		for name, err := range RunHealthChecks(request.Context(), checks) {
+			if err != nil {
+				report.Status = "fail"
+				report.Checks[name] = err.Error()
+				code = http.StatusServiceUnavailable
+				continue
+			}
+			report.Checks[name] = "ok"
+		}
+		writer.Header().Set("Content-Type", "application/json")
+		writer.WriteHeader(code)
+		_ = json.NewEncoder(writer).Encode(report)
+	})
+}
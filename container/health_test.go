@@ -0,0 +1,53 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type healthModule struct {
+	name string
+	err  error
+}
+
+func (h healthModule) ProvideHealthCheck() (string, func(ctx context.Context) error) {
+	return h.name, func(ctx context.Context) error {
+		return h.err
+	}
+}
+
+func TestContainer_HealthChecks(t *testing.T) {
+	var c Container
+	c.AddModule(healthModule{name: "db"})
+	c.AddModule(healthModule{name: "cache", err: errors.New("connection refused")})
+
+	checks := c.HealthChecks()
+	assert.Len(t, checks, 2)
+	assert.Equal(t, "db", checks[0].Name)
+	assert.Equal(t, "cache", checks[1].Name)
+
+	results := RunHealthChecks(context.Background(), checks)
+	assert.NoError(t, results["db"])
+	assert.EqualError(t, results["cache"], "connection refused")
+
+	recorder := httptest.NewRecorder()
+	HealthCheckHandler(checks).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	var report struct {
+		Status string
+		Checks map[string]string
+	}
+	assert.NoError(t, json.NewDecoder(recorder.Body).Decode(&report))
+	assert.Equal(t, "fail", report.Status)
+	assert.Equal(t, map[string]string{"db": "ok", "cache": "connection refused"}, report.Checks)
+
+	c.Reset()
+	assert.Empty(t, c.HealthChecks())
+}
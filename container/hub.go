@@ -0,0 +1,66 @@
+package container
+
+import (
+	"sync"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+// WebsocketProvider provides realtime features over websocket. The upgrade
+// handlers are still served by a HTTPProvider, and the upgraded connections are
+// registered to the Hub, so that every module can broadcast to them.
+type WebsocketProvider interface {
+	ProvideWebsocket(hub *Hub)
+}
+
+// WebsocketConn is a websocket connection. *websocket.Conn from
+// github.com/gorilla/websocket satisfies this interface.
+type WebsocketConn interface {
+	WriteMessage(messageType int, data []byte) error
+}
+
+// Hub keeps track of the websocket connections. It is safe for concurrent use,
+// and the zero value is ready to use.
+type Hub struct {
+	mu    sync.RWMutex
+	conns map[WebsocketConn]struct{}
+}
+
+// Register adds the connection to the hub.
+func (h *Hub) Register(conn WebsocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conns == nil {
+		h.conns = make(map[WebsocketConn]struct{})
+	}
+	h.conns[conn] = struct{}{}
+}
+
+// Unregister removes the connection from the hub, typically when it is closed.
+func (h *Hub) Unregister(conn WebsocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, conn)
+}
+
+// Broadcast writes the message to every registered connection. The connections
+// failing the write are unregistered, and their errors are aggregated in the
+// returned error. As websocket connections generally don't support concurrent
+// writers, make sure nothing else writes to the connections meanwhile.
+func (h *Hub) Broadcast(messageType int, data []byte) error {
+	h.mu.RLock()
+	conns := make([]WebsocketConn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+
+	var result error
+	for _, conn := range conns {
+		if err := conn.WriteMessage(messageType, data); err != nil {
+			h.Unregister(conn)
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
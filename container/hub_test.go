@@ -0,0 +1,50 @@
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type wsConn struct {
+	received [][]byte
+	err      error
+}
+
+func (w *wsConn) WriteMessage(messageType int, data []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.received = append(w.received, data)
+	return nil
+}
+
+type wsModule struct {
+	conn *wsConn
+}
+
+func (w wsModule) ProvideWebsocket(hub *Hub) {
+	hub.Register(w.conn)
+}
+
+func TestContainer_ApplyWebsocket(t *testing.T) {
+	var (
+		c      Container
+		hub    Hub
+		good   = &wsConn{}
+		broken = &wsConn{err: errors.New("closed")}
+	)
+	c.AddModule(wsModule{conn: good})
+	c.AddModule(wsModule{conn: broken})
+	c.ApplyWebsocket(&hub)
+
+	err := hub.Broadcast(1, []byte("foo"))
+	assert.EqualError(t, errors.Unwrap(err), "closed")
+	assert.NoError(t, hub.Broadcast(1, []byte("bar")))
+	assert.Equal(t, [][]byte{[]byte("foo"), []byte("bar")}, good.received)
+
+	hub.Unregister(good)
+	assert.NoError(t, hub.Broadcast(1, []byte("baz")))
+	assert.Len(t, good.received, 2)
+}
@@ -0,0 +1,114 @@
+package container
+
+import (
+	"fmt"
+	"sync"
+)
+
+// State is the lifecycle state of the Container. The states are ordered, and a
+// Container only moves forward:
+//
+//	Registering -> Built -> Starting -> Running -> Draining -> Stopped
+//
+// States may be skipped, for example a Container that is shut down without ever
+// being served goes from Registering to Draining directly.
+type State int
+
+const (
+	// Registering is the initial state, in which modules are added.
+	Registering State = iota
+	// Built means the modules are all registered and the providers are about to
+	// be applied.
+	Built
+	// Starting means the servers and the run groups are being started.
+	Starting
+	// Running means the run group has started. The listeners of the HTTP and
+	// gRPC servers are bound beforehand, but the actors of the run group,
+	// including the servers, are started concurrently, and may not be serving
+	// yet when the state is reached.
+	Running
+	// Draining means the servers are shutting down, or the closers are running.
+	Draining
+	// Stopped is the final state, reached when every closer has returned.
+	Stopped
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case Registering:
+		return "Registering"
+	case Built:
+		return "Built"
+	case Starting:
+		return "Starting"
+	case Running:
+		return "Running"
+	case Draining:
+		return "Draining"
+	case Stopped:
+		return "Stopped"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+// Transition is a change of the lifecycle state.
+type Transition struct {
+	From State
+	To   State
+}
+
+// lifecycle tracks the state. It is safe for concurrent use.
+type lifecycle struct {
+	mu          sync.Mutex
+	state       State
+	subscribers []chan Transition
+}
+
+// State returns the current lifecycle state. It is safe to call concurrently.
+func (c *Container) State() State {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	return c.lifecycle.state
+}
+
+// Transitions returns a channel that receives every transition from now on. The
+// channel is closed once the Container is Stopped, or immediately if it already
+// is. Receiving is optional, as the channel is buffered enough to never block
+// the Container.
+func (c *Container) Transitions() <-chan Transition {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	ch := make(chan Transition, int(Stopped))
+	if c.lifecycle.state == Stopped {
+		close(ch)
+		return ch
+	}
+	c.lifecycle.subscribers = append(c.lifecycle.subscribers, ch)
+	return ch
+}
+
+// Advance moves the Container to the given state, and notifies the subscribers
+// of Transitions. It returns an error if the state is not ahead of the current
+// one. Advance is called by the core at the right points of the serve command
+// and of Shutdown, so most users don't need to call it.
+func (c *Container) Advance(to State) error {
+	c.lifecycle.mu.Lock()
+	defer c.lifecycle.mu.Unlock()
+	from := c.lifecycle.state
+	if to <= from || to > Stopped {
+		return fmt.Errorf("invalid lifecycle transition from %s to %s", from, to)
+	}
+	c.lifecycle.state = to
+	for _, ch := range c.lifecycle.subscribers {
+		ch <- Transition{From: from, To: to}
+		if to == Stopped {
+			close(ch)
+		}
+	}
+	if to == Stopped {
+		c.lifecycle.subscribers = nil
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	stdlog "log"
 
 	"github.com/DoNewsCode/core/config"
+	"github.com/DoNewsCode/core/container"
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/di"
 	"github.com/DoNewsCode/core/events"
@@ -21,6 +22,9 @@ env: local
 http:
   addr: :8080
   disable: false
+  metrics:
+    path: /metrics
+    disable: false
 grpc:
   addr: :9090
   disable: false
@@ -108,6 +112,13 @@ func ProvideEventDispatcher(conf contract.ConfigAccessor) contract.Dispatcher {
 	return &events.SyncDispatcher{}
 }
 
+// ProvideContainer returns a bare Container. Unless SetContainerProvider is
+// used, package core creates a Container with its logger instead, so that
+// Container.ShutdownContext reports the closers it gives up on.
+func ProvideContainer(conf contract.ConfigAccessor) contract.Container {
+	return container.New()
+}
+
 // provideDefaultConfig exports config for "name", "version", "env", "http", "grpc".
 func provideDefaultConfig() []config.ExportedConfig {
 	return []config.ExportedConfig{
@@ -138,9 +149,13 @@ func provideDefaultConfig() []config.ExportedConfig {
 				"http": map[string]interface{}{
 					"addr":    ":8080",
 					"disable": false,
+					"metrics": map[string]interface{}{
+						"path":    "/metrics",
+						"disable": false,
+					},
 				},
 			},
-			Comment: "The http address",
+			Comment: "The http address. If a prometheus.Gatherer is provided, its metrics are exposed at http.metrics.path",
 		},
 		{
 			Owner: "core",
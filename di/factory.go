@@ -21,14 +21,41 @@ type Factory struct {
 	group       singleflight.Group
 	cache       sync.Map
 	constructor func(name string) (Pair, error)
+	validator   func(conn interface{}) bool
 	reloadOnce  sync.Once
 }
 
+// FactoryOption configures the Factory.
+type FactoryOption func(*Factory)
+
+// WithValidator is an option that validates the cached connection before it is
+// returned by Make. If the validator returns false, a new connection is
+// constructed in place, so that dead connections, for example after long idle
+// periods, are not handed out.
+//
+// The rejected connection is closed and removed from the factory, even though
+// earlier callers may still hold it. Since most clients reconnect on their own,
+// a validator should only reject connections that are unusable for good, such
+// as closed ones, rather than those failing a single probe.
+//
+// Note the validator runs on every Make of a cached connection, so its cost adds
+// to every Make. Keep it cheap, and bound it with a short timeout if it involves
+// network round trips.
+func WithValidator(validator func(conn interface{}) bool) FactoryOption {
+	return func(factory *Factory) {
+		factory.validator = validator
+	}
+}
+
 // NewFactory creates a new factory.
-func NewFactory(constructor func(name string) (Pair, error)) *Factory {
-	return &Factory{
+func NewFactory(constructor func(name string) (Pair, error), opts ...FactoryOption) *Factory {
+	f := &Factory{
 		constructor: constructor,
 	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
 }
 
 // Make creates an instance under the provided name. It an instance is already
@@ -38,7 +65,10 @@ func (f *Factory) Make(name string) (interface{}, error) {
 
 	conn, err, _ := f.group.Do(name, func() (interface{}, error) {
 		if slot, ok := f.cache.Load(name); ok {
-			return slot.(Pair).Conn, nil
+			if f.validator == nil || f.validator(slot.(Pair).Conn) {
+				return slot.(Pair).Conn, nil
+			}
+			f.CloseConn(name)
 		}
 		slot, err := f.constructor(name)
 		if err != nil {
@@ -77,8 +107,8 @@ func (f *Factory) List() map[string]Pair {
 	return out
 }
 
-// Close closes every connection created by the factory. Connections are closed
-// concurrently.
+// Close closes every connection created by the factory. Connections are
+// closed concurrently.
 func (f *Factory) Close() {
 	var wg sync.WaitGroup
 	f.cache.Range(func(key, value interface{}) bool {
@@ -99,9 +129,19 @@ func (f *Factory) Close() {
 
 // CloseConn closes a specific connection in the factory.
 func (f *Factory) CloseConn(name string) {
-	if value, loaded := f.cache.LoadAndDelete(name); loaded {
-		if value.(Pair).Closer != nil {
-			value.(Pair).Closer()
-		}
+	if pair, ok := f.Evict(name); ok && pair.Closer != nil {
+		pair.Closer()
+	}
+}
+
+// Evict removes a specific connection from the factory without closing it, and
+// returns it. The caller becomes responsible for closing it. As the connection
+// is removed first, a concurrent Make creates a new connection rather than
+// returning one being closed.
+func (f *Factory) Evict(name string) (Pair, bool) {
+	value, loaded := f.cache.LoadAndDelete(name)
+	if !loaded {
+		return Pair{}, false
 	}
+	return value.(Pair), true
 }
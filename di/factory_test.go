@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"math/rand"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -46,6 +48,31 @@ func TestFactory(t *testing.T) {
 	assert.Contains(t, closed, "foo", "bar")
 }
 
+func TestFactory_Evict(t *testing.T) {
+	t.Parallel()
+	var closed int32
+	f := NewFactory(func(name string) (Pair, error) {
+		nameCopy := name
+		return Pair{
+			Conn:   &nameCopy,
+			Closer: func() { atomic.AddInt32(&closed, 1) },
+		}, nil
+	})
+
+	foo, _ := f.Make("foo")
+	pair, ok := f.Evict("foo")
+	assert.True(t, ok)
+	assert.Same(t, foo, pair.Conn)
+	assert.Empty(t, f.List())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&closed), "the evicted connection is closed by the caller")
+
+	again, _ := f.Make("foo")
+	assert.NotSame(t, foo, again)
+
+	_, ok = f.Evict("bar")
+	assert.False(t, ok)
+}
+
 func TestFactory_nilCloser(t *testing.T) {
 	t.Parallel()
 
@@ -64,6 +91,45 @@ func TestFactory_nilCloser(t *testing.T) {
 	f.Close()
 }
 
+func TestFactory_WithValidator(t *testing.T) {
+	t.Parallel()
+	var (
+		mu     sync.Mutex
+		closed []string
+		valid  = true
+	)
+
+	f := NewFactory(func(name string) (Pair, error) {
+		nameCopy := name
+		return Pair{
+			Conn: &nameCopy,
+			Closer: func() {
+				mu.Lock()
+				closed = append(closed, name)
+				mu.Unlock()
+			},
+		}, nil
+	}, WithValidator(func(conn interface{}) bool {
+		return valid
+	}))
+
+	foo, err := f.Make("foo")
+	assert.NoError(t, err)
+	foo2, err := f.Make("foo")
+	assert.NoError(t, err)
+	assert.Same(t, foo, foo2)
+	assert.Empty(t, closed)
+
+	valid = false
+	foo3, err := f.Make("foo")
+	assert.NoError(t, err)
+	assert.NotSame(t, foo, foo3)
+	assert.Equal(t, []string{"foo"}, closed)
+
+	f.Close()
+	assert.Equal(t, []string{"foo", "foo"}, closed)
+}
+
 func TestFactory_malfunctionConstructor(t *testing.T) {
 	t.Parallel()
 
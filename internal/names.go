@@ -0,0 +1,36 @@
+package internal
+
+import (
+	"sort"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+)
+
+// ConfiguredNames returns the sorted names of the entries configured under the
+// key, such as the connection names under "redis".
+func ConfiguredNames(conf contract.ConfigAccessor, key string) []string {
+	if conf == nil {
+		return nil
+	}
+	var entries map[string]interface{}
+	_ = conf.Unmarshal(key, &entries)
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreatedNames returns the sorted names of the connections created by the
+// factory.
+func CreatedNames(factory *di.Factory) []string {
+	pairs := factory.List()
+	names := make([]string, 0, len(pairs))
+	for name := range pairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -32,10 +32,23 @@ It is most beneficial if labels are used multiple times and are scattered all
 over the place.
 
 manager is immutable, hence safe for concurrent access.
+
+Typed Labels
+
+To catch typos in label names at compile time, generate a typed builder with
+the keygen command:
+
+	//go:generate go run github.com/DoNewsCode/core/key/keygen -type Labels -dims module,service
+
+	keyer := NewLabels().Module("foo").Service("bar")
+
+See package github.com/DoNewsCode/core/key/keygen for details.
 */
 package key
 
 import (
+	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/DoNewsCode/core/contract"
@@ -56,23 +69,168 @@ func New(parts ...string) manager {
 	}
 }
 
+// MustManager is like New, but panics if the labels are not in pairs. See
+// Validate.
+func MustManager(parts ...string) manager {
+	k := New(parts...)
+	if err := k.Validate(); err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// Parse constructs a manager from a key built by Key with the same delimiter. It
+// is the inverse of Key: empty segments are preserved, and an empty string
+// yields a manager without labels.
+//
+//  manager := Parse(":", "module:foo:service:bar")
+func Parse(delimiter, s string) manager {
+	if s == "" {
+		return manager{}
+	}
+	return manager{
+		Prefixes: strings.Split(s, delimiter),
+	}
+}
+
 // Key creates a string key composed by labels stored in manager
 func (k manager) Key(delimiter string, parts ...string) string {
-	parts = append(k.Prefixes, parts...)
-	return strings.Join(parts, delimiter)
+	var builder strings.Builder
+	builder.Grow(k.size(delimiter, parts))
+	for i := range k.Prefixes {
+		if i > 0 {
+			builder.WriteString(delimiter)
+		}
+		builder.WriteString(k.Prefixes[i])
+	}
+	for i := range parts {
+		if i > 0 || len(k.Prefixes) > 0 {
+			builder.WriteString(delimiter)
+		}
+		builder.WriteString(parts[i])
+	}
+	return builder.String()
+}
+
+// KeyEscaped is like Key, but escapes the delimiter inside the labels and the
+// parts, so that the key stays unambiguous when a segment contains the
+// delimiter, for example a tenant name with a colon. The escaping scheme is
+// percent-encoding: every byte of a segment that appears in the delimiter is
+// written as %XX, and so is every percent sign. Escaping the bytes rather than
+// whole occurrences keeps multi-byte delimiters unambiguous at the segment
+// boundaries, for example "a:" followed by "b" with "::". The delimiter itself must not contain a
+// percent sign. Use ParseEscaped to get the segments back.
+//
+//	New("tenant", "a:b").KeyEscaped(":", "x") // tenant:a%3Ab:x
+func (k manager) KeyEscaped(delimiter string, parts ...string) string {
+	escaped := manager{Prefixes: make([]string, len(k.Prefixes))}
+	for i := range k.Prefixes {
+		escaped.Prefixes[i] = escape(delimiter, k.Prefixes[i])
+	}
+	escapedParts := make([]string, len(parts))
+	for i := range parts {
+		escapedParts[i] = escape(delimiter, parts[i])
+	}
+	return escaped.Key(delimiter, escapedParts...)
 }
 
-// Spread returns all labels in manager as []string.
+// KeyInto is like Key, but appends the key to buf and returns the extended
+// buffer. It doesn't allocate if buf has enough capacity, so callers on a hot
+// path can reuse the buffer between calls:
+//
+//	buf = keyer.KeyInto(buf[:0], ":", "mykey")
+func (k manager) KeyInto(buf []byte, delimiter string, parts ...string) []byte {
+	for i := range k.Prefixes {
+		if i > 0 {
+			buf = append(buf, delimiter...)
+		}
+		buf = append(buf, k.Prefixes[i]...)
+	}
+	for i := range parts {
+		if i > 0 || len(k.Prefixes) > 0 {
+			buf = append(buf, delimiter...)
+		}
+		buf = append(buf, parts[i]...)
+	}
+	return buf
+}
+
+// size returns the length of the key.
+func (k manager) size(delimiter string, parts []string) int {
+	count := len(k.Prefixes) + len(parts)
+	if count == 0 {
+		return 0
+	}
+	size := len(delimiter) * (count - 1)
+	for i := range k.Prefixes {
+		size += len(k.Prefixes[i])
+	}
+	for i := range parts {
+		size += len(parts[i])
+	}
+	return size
+}
+
+// ParseEscaped is the inverse of KeyEscaped. It returns an error if a segment
+// contains a malformed escape sequence.
+func ParseEscaped(delimiter, s string) (manager, error) {
+	k := Parse(delimiter, s)
+	for i := range k.Prefixes {
+		segment, err := url.PathUnescape(k.Prefixes[i])
+		if err != nil {
+			return manager{}, fmt.Errorf("key: malformed segment %q: %w", k.Prefixes[i], err)
+		}
+		k.Prefixes[i] = segment
+	}
+	return k, nil
+}
+
+// escape percent-encodes the percent signs and the bytes of the delimiter in
+// the segment.
+func escape(delimiter, segment string) string {
+	if !strings.ContainsAny(segment, "%"+delimiter) {
+		return segment
+	}
+	var escaped strings.Builder
+	escaped.Grow(len(segment))
+	for i := 0; i < len(segment); i++ {
+		if segment[i] == '%' || strings.IndexByte(delimiter, segment[i]) >= 0 {
+			fmt.Fprintf(&escaped, "%%%02X", segment[i])
+			continue
+		}
+		escaped.WriteByte(segment[i])
+	}
+	return escaped.String()
+}
+
+// Validate returns an error if the labels are not alternating key values, that
+// is, if their number is odd. Such labels are silently misaligned by the
+// consumers of Spread, such as log.With and metrics.Counter.With.
+func (k manager) Validate() error {
+	if len(k.Prefixes)%2 != 0 {
+		return fmt.Errorf("key: odd number of labels %d, labels must be key value pairs", len(k.Prefixes))
+	}
+	return nil
+}
+
+// Spread returns all labels in manager as []string. The slice is a copy, so
+// modifying or appending to it doesn't affect the manager.
 func (k manager) Spread() []string {
-	return k.Prefixes
+	if k.Prefixes == nil {
+		return nil
+	}
+	out := make([]string, len(k.Prefixes))
+	copy(out, k.Prefixes)
+	return out
 }
 
 // With returns a new manager with added alternating key values.
 // Note: manager is immutable. With Creates a new instance.
 func (k manager) With(parts ...string) manager {
-	newKeyManager := manager{}
-	newKeyManager.Prefixes = append(k.Prefixes, parts...)
-	return newKeyManager
+	prefixes := make([]string, 0, len(k.Prefixes)+len(parts))
+	prefixes = append(prefixes, k.Prefixes...)
+	prefixes = append(prefixes, parts...)
+	return manager{Prefixes: prefixes}
 }
 
 // With returns a new manager with added alternating key values.
@@ -95,8 +253,7 @@ func SpreadInterface(k contract.Keyer) []interface{} {
 
 // KeepOdd only retains the odd values in the contract.Keyer. Note: The
 // alternating key-values count from zero. Odd values are the "value" in
-// key-value pairs. To avoid confusion, the KeepEven method is intentionally not
-// provided.
+// key-value pairs. See KeepEven for the "key" side.
 func KeepOdd(k contract.Keyer) contract.Keyer {
 	var (
 		spreader = k.Spread()
@@ -109,3 +266,21 @@ func KeepOdd(k contract.Keyer) contract.Keyer {
 	}
 	return km
 }
+
+// KeepEven only retains the even values in the contract.Keyer. The alternating
+// key-values count from zero, so even values are the "key" in key-value pairs,
+// that is, the label names:
+//
+//	KeepEven(New("module", "foo", "service", "bar")).Key(":") // module:service
+func KeepEven(k contract.Keyer) contract.Keyer {
+	var (
+		spreader = k.Spread()
+		km       = manager{}
+	)
+	for i := range spreader {
+		if i%2 == 0 {
+			km.Prefixes = append(km.Prefixes, spreader[i])
+		}
+	}
+	return km
+}
@@ -0,0 +1,173 @@
+package key
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager_Key(t *testing.T) {
+	cases := []struct {
+		name     string
+		manager  manager
+		parts    []string
+		expected string
+	}{
+		{"empty", New(), nil, ""},
+		{"prefixes only", New("foo", "bar"), nil, "foo:bar"},
+		{"parts only", New(), []string{"baz"}, "baz"},
+		{"both", New("foo", "bar"), []string{"baz", "qux"}, "foo:bar:baz:qux"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.manager.Key(":", c.parts...))
+			assert.Equal(t, c.expected, string(c.manager.KeyInto(nil, ":", c.parts...)))
+		})
+	}
+}
+
+func TestManager_KeyDoesNotCorruptPrefixes(t *testing.T) {
+	// prefixes with spare capacity used to be overwritten by append.
+	prefixes := make([]string, 2, 10)
+	prefixes[0], prefixes[1] = "module", "foo"
+	k := New(prefixes...)
+
+	assert.Equal(t, "module:foo:a", k.Key(":", "a"))
+	assert.Equal(t, "module:foo:b:c", k.Key(":", "b", "c"))
+	assert.Equal(t, "module:foo:a", k.Key(":", "a"))
+	assert.Equal(t, []string{"module", "foo"}, k.Spread())
+	assert.Equal(t, "", prefixes[:3][2])
+}
+
+func TestManager_SpreadReturnsCopy(t *testing.T) {
+	k := New("module", "foo")
+
+	spread := k.Spread()
+	spread[0] = "x"
+	assert.Equal(t, []string{"module", "foo"}, k.Spread())
+
+	_ = append(k.Spread()[:1], "y")
+	assert.Equal(t, []string{"module", "foo"}, k.Spread())
+	assert.Equal(t, "module:foo", k.Key(":"))
+}
+
+func TestManager_WithDoesNotAlias(t *testing.T) {
+	prefixes := make([]string, 2, 10)
+	prefixes[0], prefixes[1] = "module", "foo"
+	base := New(prefixes...)
+
+	var wg sync.WaitGroup
+	derived := make([]manager, 100)
+	for i := range derived {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			derived[i] = base.With("id", strconv.Itoa(i))
+		}(i)
+	}
+	wg.Wait()
+
+	for i, k := range derived {
+		assert.Equal(t, []string{"module", "foo", "id", strconv.Itoa(i)}, k.Spread())
+	}
+	assert.Equal(t, []string{"module", "foo"}, base.Spread())
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name    string
+		manager manager
+	}{
+		{"empty", New()},
+		{"one", New("foo")},
+		{"many", New("module", "foo", "service", "bar")},
+		{"empty segments", New("module", "", "", "bar")},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			parsed := Parse(":", c.manager.Key(":"))
+			assert.Equal(t, c.manager.Spread(), parsed.Spread())
+			assert.Equal(t, c.manager.Key(":"), parsed.Key(":"))
+		})
+	}
+	assert.Empty(t, Parse(":", "").Spread())
+}
+
+func TestManager_KeyEscaped(t *testing.T) {
+	cases := []struct {
+		name      string
+		delimiter string
+		manager   manager
+		parts     []string
+		expected  string
+	}{
+		{"plain", ":", New("tenant", "foo"), []string{"x"}, "tenant:foo:x"},
+		{"delimiter", ":", New("tenant", "a:b"), []string{"x:y"}, "tenant:a%3Ab:x%3Ay"},
+		{"percent", ":", New("tenant", "100%"), nil, "tenant:100%25"},
+		{"long delimiter", "::", New("tenant", "a::b:c"), nil, "tenant::a%3A%3Ab%3Ac"},
+		{"long delimiter boundary", "::", New("a:"), []string{"b"}, "a%3A::b"},
+		{"empty segments", ":", New("tenant", ""), []string{""}, "tenant::"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			key := c.manager.KeyEscaped(c.delimiter, c.parts...)
+			assert.Equal(t, c.expected, key)
+
+			parsed, err := ParseEscaped(c.delimiter, key)
+			assert.NoError(t, err)
+			assert.Equal(t, append(c.manager.Spread(), c.parts...), parsed.Spread())
+		})
+	}
+
+	_, err := ParseEscaped(":", "tenant:%zz")
+	assert.Error(t, err)
+}
+
+func TestKeepEven(t *testing.T) {
+	k := New("module", "foo", "service", "bar")
+	assert.Equal(t, []string{"module", "service"}, KeepEven(k).Spread())
+	assert.Equal(t, []string{"foo", "bar"}, KeepOdd(k).Spread())
+	assert.Empty(t, KeepEven(New()).Spread())
+}
+
+func TestManager_Validate(t *testing.T) {
+	assert.NoError(t, New().Validate())
+	assert.NoError(t, New("module", "foo").Validate())
+	assert.Error(t, New("module", "foo", "service").Validate())
+
+	assert.NotPanics(t, func() { MustManager("module", "foo") })
+	assert.Panics(t, func() { MustManager("module") })
+}
+
+func TestManager_KeyInto(t *testing.T) {
+	k := New("module", "foo")
+	buf := make([]byte, 0, 64)
+	buf = k.KeyInto(buf, ":", "a")
+	assert.Equal(t, "module:foo:a", string(buf))
+	buf = k.KeyInto(buf[:0], ":", "b")
+	assert.Equal(t, "module:foo:b", string(buf))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = k.KeyInto(buf[:0], ":", "a")
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkManager_Key(b *testing.B) {
+	k := New("module", "foo", "service", "bar")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = k.Key(":", "mykey")
+	}
+}
+
+func BenchmarkManager_KeyInto(b *testing.B) {
+	k := New("module", "foo", "service", "bar")
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = k.KeyInto(buf[:0], ":", "mykey")
+	}
+}
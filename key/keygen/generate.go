@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+type dimension struct {
+	Name   string
+	Method string
+}
+
+var tmpl = template.Must(template.New("keygen").Parse(`// Code generated by keygen. DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+)
+
+// {{ .Type }} is a typed label builder with the dimensions:{{ range .Dims }} {{ .Name }}{{ end }}.
+// It implements contract.Keyer, and is immutable.
+type {{ .Type }} struct {
+	contract.Keyer
+}
+
+// New{{ .Type }} creates an empty {{ .Type }}.
+func New{{ .Type }}() {{ .Type }} {
+	return {{ .Type }}{key.New()}
+}
+{{ range .Dims }}
+// {{ .Method }} returns a new {{ $.Type }} with the {{ .Name }} label added.
+func (l {{ $.Type }}) {{ .Method }}(value string) {{ $.Type }} {
+	return {{ $.Type }}{key.With(l.Keyer, "{{ .Name }}", value)}
+}
+{{ end -}}
+`))
+
+// generate renders the builder source code.
+func generate(pkg, typeName string, dims []string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(typeName) {
+		return nil, fmt.Errorf("invalid type name %q", typeName)
+	}
+	var (
+		dimensions []dimension
+		seen       = make(map[string]bool)
+	)
+	for _, dim := range dims {
+		dim = strings.TrimSpace(dim)
+		method := methodName(dim)
+		if !token.IsIdentifier(method) {
+			return nil, fmt.Errorf("invalid dimension %q", dim)
+		}
+		if seen[method] || method == "Key" || method == "Spread" {
+			return nil, fmt.Errorf("duplicated or reserved dimension %q", dim)
+		}
+		seen[method] = true
+		dimensions = append(dimensions, dimension{Name: dim, Method: method})
+	}
+	if len(dimensions) == 0 {
+		return nil, fmt.Errorf("no dimension given")
+	}
+
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, struct {
+		Package string
+		Type    string
+		Dims    []dimension
+	}{pkg, typeName, dimensions})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// methodName converts lowerCamel or snake case dimensions to exported method
+// names, eg. service_name to ServiceName.
+func methodName(dim string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range dim {
+		if r == '_' || r == '-' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	expected, err := ioutil.ReadFile("./internal/example/labels_gen.go")
+	assert.NoError(t, err)
+	src, err := generate("example", "Labels", []string{"module", " service"})
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(src))
+}
+
+func TestGenerate_invalid(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name     string
+		pkg      string
+		typeName string
+		dims     []string
+	}{
+		{"package", "1pkg", "Labels", []string{"module"}},
+		{"type", "example", "", []string{"module"}},
+		{"no dims", "example", "Labels", []string{""}},
+		{"invalid dim", "example", "Labels", []string{"mod ule"}},
+		{"duplicated dim", "example", "Labels", []string{"module", "Module"}},
+		{"reserved dim", "example", "Labels", []string{"key"}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := generate(c.pkg, c.typeName, c.dims)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "Module", methodName("module"))
+	assert.Equal(t, "ServiceName", methodName("service_name"))
+	assert.Equal(t, "ServiceName", methodName("serviceName"))
+}
@@ -0,0 +1,17 @@
+package example_test
+
+import (
+	"fmt"
+
+	"github.com/DoNewsCode/core/key"
+	"github.com/DoNewsCode/core/key/keygen/internal/example"
+)
+
+func Example() {
+	keyer := example.NewLabels().Module("foo").Service("bar")
+	fmt.Println(keyer.Spread())
+	fmt.Println(key.KeepOdd(keyer).Key(":", "mykey"))
+	// Output:
+	// [module foo service bar]
+	// foo:bar:mykey
+}
@@ -0,0 +1,4 @@
+// Package example demonstrates the code generated by keygen.
+package example
+
+//go:generate go run github.com/DoNewsCode/core/key/keygen -type Labels -dims module,service
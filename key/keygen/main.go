@@ -0,0 +1,56 @@
+/*
+Command keygen generates typed label builders on top of package key.
+
+Instead of building keyers with alternating strings, which are prone to typos:
+
+	keyer := key.New("module", "foo", "servcie", "bar")
+
+declare the dimensions once and generate a builder:
+
+	//go:generate go run github.com/DoNewsCode/core/key/keygen -type Labels -dims module,service
+
+The generated builder has a method per dimension, and implements contract.Keyer:
+
+	keyer := NewLabels().Module("foo").Service("bar")
+
+Flags:
+
+	-type     the name of the generated type (required)
+	-dims     comma separated dimensions, in lowerCamel or snake case (required)
+	-package  the package name, defaults to $GOPACKAGE set by go generate
+	-output   the output file, defaults to <type>_gen.go in lower case
+
+The generated code only depends on package key and package contract.
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	var (
+		typeName = flag.String("type", "", "the name of the generated type")
+		dims     = flag.String("dims", "", "comma separated dimensions")
+		pkg      = flag.String("package", os.Getenv("GOPACKAGE"), "the package name")
+		output   = flag.String("output", "", "the output file")
+	)
+	flag.Parse()
+
+	if *output == "" {
+		*output = strings.ToLower(*typeName) + "_gen.go"
+	}
+	src, err := generate(*pkg, *typeName, strings.Split(*dims, ","))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "keygen: %s\n", err)
+		os.Exit(1)
+	}
+	if err := ioutil.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "keygen: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -20,6 +20,8 @@ package logging
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -111,6 +113,38 @@ func WithContext(logger log.Logger, ctx context.Context) log.Logger {
 	return spanLogger{span: span, base: withContext(logger, ctx)}
 }
 
+// TraceID returns the trace id of the span in the context, so that logs can be
+// correlated with traces. The span context is injected as http headers by the
+// tracer of the span, and the trace id is read from the Jaeger (uber-trace-id),
+// B3 (X-B3-TraceId) or W3C (traceparent) header, so that it doesn't depend on
+// the tracing backend. It returns an empty string if there is no span, or if
+// none of these headers is injected.
+func TraceID(ctx context.Context) string {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return ""
+	}
+	header := make(http.Header)
+	if err := span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		return ""
+	}
+	if value := header.Get("uber-trace-id"); value != "" {
+		// Jaeger url-encodes the "trace-id:span-id:parent-id:flags" value.
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		return strings.SplitN(value, ":", 2)[0]
+	}
+	if value := header.Get("X-B3-TraceId"); value != "" {
+		return value
+	}
+	// The W3C value is "version-trace-id-parent-id-flags".
+	if parts := strings.Split(header.Get("traceparent"), "-"); len(parts) == 4 {
+		return parts[1]
+	}
+	return ""
+}
+
 func withContext(logger log.Logger, ctx context.Context) log.Logger {
 	transport, _ := ctx.Value(contract.TransportKey).(string)
 	requestUrl, _ := ctx.Value(contract.RequestUrlKey).(string)
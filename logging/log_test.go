@@ -2,11 +2,22 @@ package logging
 
 import (
 	"bytes"
+	"context"
+	"sync"
 	"testing"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+	otbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/propagation"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 func TestWithLevel(t *testing.T) {
@@ -29,3 +40,55 @@ func TestLevelFilter(t *testing.T) {
 func TestNewLogger(t *testing.T) {
 	_ = NewLogger("logfmt")
 }
+
+func TestTraceID(t *testing.T) {
+	assert.Empty(t, TraceID(context.Background()))
+
+	t.Run("jaeger", func(t *testing.T) {
+		tracer, closer := jaeger.NewTracer("test", jaeger.NewConstSampler(true), jaeger.NewNullReporter())
+		defer closer.Close()
+		span, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "test")
+		defer span.Finish()
+		assert.Equal(t, span.Context().(jaeger.SpanContext).TraceID().String(), TraceID(ctx))
+		assert.NotEmpty(t, TraceID(ctx))
+	})
+
+	t.Run("zipkin", func(t *testing.T) {
+		native, err := zipkin.NewTracer(reporter.NewNoopReporter())
+		assert.NoError(t, err)
+		span, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), zipkinot.Wrap(native), "test")
+		defer span.Finish()
+		assert.Equal(t, span.Context().(zipkinot.SpanContext).TraceID.String(), TraceID(ctx))
+		assert.NotEmpty(t, TraceID(ctx))
+	})
+
+	t.Run("opentelemetry", func(t *testing.T) {
+		exporter := &memoryExporter{}
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		bridge, _ := otbridge.NewTracerPair(provider.Tracer("test"))
+		bridge.SetTextMapPropagator(propagation.TraceContext{})
+		span, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), bridge, "test")
+		traceID := TraceID(ctx)
+		span.Finish()
+		assert.NotEmpty(t, traceID)
+		if assert.Len(t, exporter.spans, 1) {
+			assert.Equal(t, exporter.spans[0].SpanContext.TraceID.String(), traceID)
+		}
+	})
+}
+
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []*exporttrace.SpanSnapshot
+}
+
+func (m *memoryExporter) ExportSpans(ctx context.Context, spans []*exporttrace.SpanSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func (m *memoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
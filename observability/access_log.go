@@ -0,0 +1,115 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+	"github.com/DoNewsCode/core/logging"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// AccessLogOption configures AccessLog.
+type AccessLogOption func(*accessLog)
+
+// WithAccessLogSampling is an option that limits the access logs under high
+// load. Within every second, the first requests are all logged, and thereafter
+// only one in every thereafter requests is. Server errors are always logged. A
+// thereafter of zero drops everything beyond the first requests. By default,
+// every request is logged.
+func WithAccessLogSampling(first, thereafter int) AccessLogOption {
+	return func(a *accessLog) {
+		a.sampler = &accessLogSampler{first: first, thereafter: thereafter}
+	}
+}
+
+// WithAccessLogExtractor is an option that adds the labels extracted from the
+// request context to every access log, for example the tenant or the user.
+// GetBaggage can be used as an extractor to log the baggage items.
+func WithAccessLogExtractor(extractor func(ctx context.Context) contract.Keyer) AccessLogOption {
+	return func(a *accessLog) {
+		a.extractors = append(a.extractors, extractor)
+	}
+}
+
+// AccessLog returns a middleware that logs one line per request at info level,
+// with the method, path, status, duration, bytes written and the trace id if
+// available. Install it inside of HTTPServerTracing so that the trace id is
+// known.
+//
+//	router.Use(
+//		observability.HTTPServerTracing(tracer),
+//		observability.AccessLog(logger, observability.WithAccessLogSampling(100, 10)),
+//	)
+func AccessLog(logger log.Logger, opts ...AccessLogOption) func(http.Handler) http.Handler {
+	a := &accessLog{logger: level.Info(logger)}
+	for _, f := range opts {
+		f(a)
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			begin := time.Now()
+			recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+			next.ServeHTTP(recorder, request)
+			a.log(request, recorder, time.Since(begin))
+		})
+	}
+}
+
+type accessLog struct {
+	logger     log.Logger
+	sampler    *accessLogSampler
+	extractors []func(ctx context.Context) contract.Keyer
+}
+
+func (a *accessLog) log(request *http.Request, recorder *statusRecorder, duration time.Duration) {
+	if a.sampler != nil && recorder.status < http.StatusInternalServerError && !a.sampler.sample(time.Now()) {
+		return
+	}
+	keyer := key.New(
+		"method", request.Method,
+		"path", request.URL.Path,
+		"status", strconv.Itoa(recorder.status),
+		"duration", duration.String(),
+		"bytes", strconv.Itoa(recorder.written),
+	)
+	if traceID := logging.TraceID(request.Context()); traceID != "" {
+		keyer = keyer.With("traceId", traceID)
+	}
+	for _, extractor := range a.extractors {
+		keyer = key.With(keyer, extractor(request.Context()).Spread()...)
+	}
+	_ = a.logger.Log(append([]interface{}{"msg", "access"}, key.SpreadInterface(keyer)...)...)
+}
+
+// accessLogSampler samples the first requests of every second, and one in every
+// thereafter requests after that.
+type accessLogSampler struct {
+	first, thereafter int
+
+	mu     sync.Mutex
+	second int64
+	count  int
+}
+
+func (s *accessLogSampler) sample(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if second := now.Unix(); second != s.second {
+		s.second = second
+		s.count = 0
+	}
+	s.count++
+	if s.count <= s.first {
+		return true
+	}
+	if s.thereafter <= 0 {
+		return false
+	}
+	return (s.count-s.first)%s.thereafter == 0
+}
@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+	"github.com/go-kit/kit/log"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	handler := AccessLog(
+		log.NewLogfmtLogger(&buf),
+		WithAccessLogExtractor(func(ctx context.Context) contract.Keyer {
+			return key.New("tenant", "foo")
+		}),
+	)(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusCreated)
+		writer.Write([]byte("hello"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/foo", nil))
+	line := buf.String()
+	assert.Contains(t, line, "level=info")
+	assert.Contains(t, line, "method=POST")
+	assert.Contains(t, line, "path=/foo")
+	assert.Contains(t, line, "status=201")
+	assert.Contains(t, line, "bytes=5")
+	assert.Contains(t, line, "duration=")
+	assert.Contains(t, line, "tenant=foo")
+}
+
+func TestAccessLog_hijack(t *testing.T) {
+	var buf bytes.Buffer
+	native, err := zipkin.NewTracer(reporter.NewNoopReporter())
+	assert.NoError(t, err)
+	handler := HTTPServerTracing(zipkinot.Wrap(native))(
+		AccessLog(log.NewLogfmtLogger(&buf))(http.HandlerFunc(switchProtocols)),
+	)
+
+	assert.Equal(t, http.StatusSwitchingProtocols, upgrade(t, handler))
+	assert.Contains(t, buf.String(), "status=101")
+	assert.Contains(t, buf.String(), "traceId=")
+}
+
+func TestAccessLog_sampling(t *testing.T) {
+	var buf bytes.Buffer
+	handler := AccessLog(log.NewLogfmtLogger(&buf), WithAccessLogSampling(2, 3))(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/error" {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	for i := 0; i < 8; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/error", nil))
+	lines := strings.Count(buf.String(), "\n")
+	// 2 first, 2 of the 6 thereafter, plus the error. The count may be higher if
+	// the second rolls over in between.
+	assert.GreaterOrEqual(t, lines, 5)
+	assert.Contains(t, buf.String(), "status=500")
+}
+
+func TestAccessLogSampler(t *testing.T) {
+	s := &accessLogSampler{first: 1, thereafter: 2}
+	now := time.Unix(100, 0)
+	assert.True(t, s.sample(now))
+	assert.False(t, s.sample(now))
+	assert.True(t, s.sample(now))
+	assert.False(t, s.sample(now))
+	assert.True(t, s.sample(now.Add(time.Second)))
+}
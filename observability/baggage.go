@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"context"
+	"sort"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/key"
+	"github.com/opentracing/opentracing-go"
+)
+
+// SetBaggage sets the alternating key values as baggage items of the span in
+// the context. Baggage travels with the trace, so the items are also available
+// to the downstream services called through traced clients, such as clihttp
+// and otkafka. It does nothing if there is no span in the context. A dangling
+// key without value is ignored.
+func SetBaggage(ctx context.Context, kvs ...string) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	for i := 0; i+1 < len(kvs); i += 2 {
+		span.SetBaggageItem(kvs[i], kvs[i+1])
+	}
+}
+
+// GetBaggage returns the baggage items of the span in the context as a
+// contract.Keyer, sorted by key, so that they can be used as log or metrics
+// labels:
+//
+//	logger = log.With(logger, key.SpreadInterface(observability.GetBaggage(ctx))...)
+//
+// Unlike baggage, a contract.Keyer only lives in the process. To propagate it,
+// set it as baggage, or carry it in headers explicitly.
+func GetBaggage(ctx context.Context) contract.Keyer {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return key.New()
+	}
+	items := make(map[string]string)
+	span.Context().ForeachBaggageItem(func(k, v string) bool {
+		items[k] = v
+		return true
+	})
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, k, items[k])
+	}
+	return key.New(kvs...)
+}
+
+// TagFromContext copies the baggage items of the given keys, such as the tenant
+// or the request id, from the span in the context to the span as tags, so that
+// the identifiers show up on child spans without re-tagging them by hand:
+//
+//	span, ctx := opentracing.StartSpanFromContext(ctx, "query")
+//	observability.TagFromContext(ctx, span, "tenant", "requestId")
+//
+// All the baggage items are copied if no key is given. Missing items are
+// skipped, and it does nothing if there is no span in the context.
+func TagFromContext(ctx context.Context, span opentracing.Span, keys ...string) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return
+	}
+	if len(keys) == 0 {
+		parent.Context().ForeachBaggageItem(func(k, v string) bool {
+			span.SetTag(k, v)
+			return true
+		})
+		return
+	}
+	for _, k := range keys {
+		if v := parent.BaggageItem(k); v != "" {
+			span.SetTag(k, v)
+		}
+	}
+}
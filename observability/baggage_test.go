@@ -0,0 +1,51 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaggage(t *testing.T) {
+	assert.Empty(t, GetBaggage(context.Background()).Spread())
+	SetBaggage(context.Background(), "tenant", "foo")
+
+	tracer := mocktracer.New()
+	span, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "test")
+	defer span.Finish()
+
+	SetBaggage(ctx, "tenant", "foo", "flag", "on", "dangling")
+	keyer := GetBaggage(ctx)
+	assert.Equal(t, []string{"flag", "on", "tenant", "foo"}, keyer.Spread())
+	assert.Equal(t, "flag:on:tenant:foo", keyer.Key(":"))
+
+	child, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "child")
+	defer child.Finish()
+	assert.Equal(t, "foo", child.BaggageItem("tenant"))
+	assert.Equal(t, []string{"flag", "on", "tenant", "foo"}, GetBaggage(ctx).Spread())
+}
+
+func TestTagFromContext(t *testing.T) {
+	tracer := mocktracer.New()
+	span := tracer.StartSpan("noop")
+	TagFromContext(context.Background(), span, "tenant")
+	span.Finish()
+	assert.Empty(t, span.(*mocktracer.MockSpan).Tags())
+
+	parent, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "parent")
+	defer parent.Finish()
+	SetBaggage(ctx, "tenant", "foo", "requestId", "bar")
+
+	child, _ := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "child")
+	TagFromContext(ctx, child, "tenant", "missing")
+	child.Finish()
+	assert.Equal(t, map[string]interface{}{"tenant": "foo"}, child.(*mocktracer.MockSpan).Tags())
+
+	all, _ := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "all")
+	TagFromContext(ctx, all)
+	all.Finish()
+	assert.Equal(t, map[string]interface{}{"tenant": "foo", "requestId": "bar"}, all.(*mocktracer.MockSpan).Tags())
+}
@@ -16,5 +16,111 @@ Add the observabilities to core:
 	c.provide(observability.Providers())
 
 See example for usage.
+
+To continue the traces started by clients, install the server side middlewares:
+
+	router.Use(observability.HTTPServerTracing(tracer))
+	grpc.NewServer(grpc.UnaryInterceptor(observability.GRPCUnaryServerTracing(tracer)))
+
+Zipkin
+
+The tracer reports to jaeger by default. To report to zipkin instead, switch
+the backend in the configuration:
+
+	tracing:
+	  backend: zipkin
+	zipkin:
+	  reporter:
+	    url: http://127.0.0.1:9411/api/v2/spans
+
+Both backends provide the same opentracing.Tracer, so the traced clients are
+unaffected.
+
+OpenTelemetry
+
+Setting the backend to otlp bridges the opentracing API to OpenTelemetry, and
+exports the spans over OTLP gRPC to a collector:
+
+	tracing:
+	  backend: otlp
+	otlp:
+	  endpoint: 127.0.0.1:4317
+	  insecure: true
+
+The opentracing spans are translated as follows. The "span.kind" tag given at
+start becomes the span kind, and "error" set to true becomes the error status.
+Other tags become attributes of the same keys, and logs become events. Span
+contexts and baggage are propagated with the W3C traceparent, tracestate and
+baggage headers, rather than the headers of jaeger or zipkin. Services calling
+each other should use the same backend to join the same trace.
+
+Baggage
+
+Request scoped metadata, such as the tenant or feature flags, can be attached to
+the trace as baggage, and read back as a contract.Keyer for log and metrics
+labels:
+
+	observability.SetBaggage(ctx, "tenant", "foo")
+	counter.With(observability.GetBaggage(ctx).Spread()...).Add(1)
+
+Baggage crosses process boundaries through the traced clients, such as clihttp
+and otkafka. Plain contract.Keyer values don't, unless carried in headers.
+
+TagFromContext copies baggage items as tags of a span, so that the identifiers
+are searchable on the spans of every service:
+
+	observability.TagFromContext(ctx, span, "tenant")
+
+Degradation
+
+When the jaeger agent or collector is unreachable, spans pile up in the reporter
+queue and eventually get dropped. To keep the tracing pipeline from harming the
+application, the tracer can be configured to degrade:
+
+	jaeger:
+	  degradation:
+	    threshold: 100
+	    interval: 10s
+	    samplingRate: 0.01
+
+If more than threshold spans are dropped within an interval, only samplingRate
+of the traces are kept. The tracer recovers once an interval passes without any
+dropped span. A threshold of 0 disables the degradation. Use
+observability.TracerDegraded to check the current state.
+
+Access Log
+
+AccessLog is a middleware that writes one structured line per request. Under
+high load, sample the lines, and add labels from the request context, such as
+the baggage items:
+
+	router.Use(observability.AccessLog(
+		logger,
+		observability.WithAccessLogSampling(100, 10),
+		observability.WithAccessLogExtractor(observability.GetBaggage),
+	))
+
+Metrics
+
+The metrics of all packages are registered with the prometheus.Registerer
+provided by ProvideRegisterer, and exposed at http.metrics.path. Modules can
+register their own with ProvideCounter and ProvideHistogram:
+
+	func NewModule(registerer prometheus.Registerer, conf contract.ConfigAccessor) (*Module, error) {
+		latency, err := observability.ProvideHistogram(registerer, conf, prometheus.HistogramOpts{
+			Name: "module_duration_seconds",
+			Help: "Time spent in the module.",
+		}, "method")
+		...
+	}
+
+The histogram buckets default to the "metrics.buckets" configuration, so that
+ops can tune them per deployment:
+
+	metrics:
+	  buckets: [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+
+ApplyMetrics serves the metrics at /metrics on routers other than the one of
+package core.
 */
 package observability
@@ -0,0 +1,197 @@
+package observability
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/uber/jaeger-client-go"
+	jaegermetric "github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/atomic"
+)
+
+const (
+	defaultDegradationInterval     = 10 * time.Second
+	defaultDegradationSamplingRate = 0.01
+)
+
+// HealthAwareSampler is a jaeger sampler that protects the application from an
+// unhealthy tracing pipeline. It wraps the configured sampler and watches the
+// number of spans dropped by the reporter. Once more than the threshold of
+// spans are dropped within an interval, the sampler enters the degraded state,
+// where only the given fraction of the otherwise sampled traces are kept. It
+// recovers after a full interval without any dropped span.
+type HealthAwareSampler struct {
+	jaeger.SamplerV2Base
+
+	delegate     jaeger.SamplerV2
+	logger       jaeger.Logger
+	threshold    int64
+	samplingRate float64
+	dropped      *atomic.Int64
+	degraded     atomic.Bool
+	stop         chan struct{}
+	stopped      atomic.Bool
+}
+
+func newHealthAwareSampler(
+	delegate jaeger.Sampler,
+	dropped *atomic.Int64,
+	logger jaeger.Logger,
+	threshold int64,
+	interval time.Duration,
+	samplingRate float64,
+) *HealthAwareSampler {
+	var v2 jaeger.SamplerV2
+	if s, ok := delegate.(jaeger.SamplerV2); ok {
+		v2 = s
+	} else {
+		v2 = samplerV1Adapter{delegate}
+	}
+	s := &HealthAwareSampler{
+		delegate:     v2,
+		dropped:      dropped,
+		logger:       logger,
+		threshold:    threshold,
+		samplingRate: samplingRate,
+		stop:         make(chan struct{}),
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.check()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+// Degraded reports whether the sampler is currently degraded.
+func (s *HealthAwareSampler) Degraded() bool {
+	return s.degraded.Load()
+}
+
+func (s *HealthAwareSampler) check() {
+	dropped := s.dropped.Swap(0)
+	if dropped > s.threshold && s.degraded.CAS(false, true) {
+		s.logger.Error(fmt.Sprintf(
+			"%d spans dropped, tracing degraded to sampling rate %g",
+			dropped,
+			s.samplingRate,
+		))
+		return
+	}
+	if dropped == 0 && s.degraded.CAS(true, false) {
+		s.logger.Infof("no spans dropped, tracing recovered")
+	}
+}
+
+func (s *HealthAwareSampler) decide(span *jaeger.Span, decision jaeger.SamplingDecision) jaeger.SamplingDecision {
+	if !decision.Sample || !s.degraded.Load() {
+		return decision
+	}
+	if float64(span.SpanContext().TraceID().Low%10000) < s.samplingRate*10000 {
+		return decision
+	}
+	return jaeger.SamplingDecision{Sample: false, Retryable: false}
+}
+
+// OnCreateSpan implements jaeger.SamplerV2.
+func (s *HealthAwareSampler) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return s.decide(span, s.delegate.OnCreateSpan(span))
+}
+
+// OnSetOperationName implements jaeger.SamplerV2.
+func (s *HealthAwareSampler) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	return s.decide(span, s.delegate.OnSetOperationName(span, operationName))
+}
+
+// OnSetTag implements jaeger.SamplerV2.
+func (s *HealthAwareSampler) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	return s.decide(span, s.delegate.OnSetTag(span, key, value))
+}
+
+// OnFinishSpan implements jaeger.SamplerV2.
+func (s *HealthAwareSampler) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return s.decide(span, s.delegate.OnFinishSpan(span))
+}
+
+// Close implements jaeger.SamplerV2. It stops the health check and closes the
+// underlying sampler.
+func (s *HealthAwareSampler) Close() {
+	if s.stopped.CAS(false, true) {
+		close(s.stop)
+	}
+	s.delegate.Close()
+}
+
+// TracerDegraded reports whether the tracer is currently degraded because of
+// dropped spans. It always returns false for tracers not created by
+// ProvideOpentracing with degradation enabled.
+func TracerDegraded(tracer opentracing.Tracer) bool {
+	t, ok := tracer.(*jaeger.Tracer)
+	if !ok {
+		return false
+	}
+	s, ok := t.Sampler().(*HealthAwareSampler)
+	if !ok {
+		return false
+	}
+	return s.Degraded()
+}
+
+type samplerV1Adapter struct {
+	jaeger.Sampler
+}
+
+func (s samplerV1Adapter) OnCreateSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	sampled, tags := s.IsSampled(span.SpanContext().TraceID(), span.OperationName())
+	return jaeger.SamplingDecision{Sample: sampled, Tags: tags}
+}
+
+func (s samplerV1Adapter) OnSetOperationName(span *jaeger.Span, operationName string) jaeger.SamplingDecision {
+	sampled, tags := s.IsSampled(span.SpanContext().TraceID(), operationName)
+	return jaeger.SamplingDecision{Sample: sampled, Tags: tags}
+}
+
+func (s samplerV1Adapter) OnSetTag(span *jaeger.Span, key string, value interface{}) jaeger.SamplingDecision {
+	return jaeger.SamplingDecision{Sample: false, Retryable: true}
+}
+
+func (s samplerV1Adapter) OnFinishSpan(span *jaeger.Span) jaeger.SamplingDecision {
+	return jaeger.SamplingDecision{Sample: false, Retryable: true}
+}
+
+// droppedSpanFactory is a jaeger metrics factory that counts the spans dropped
+// by the reporter, while forwarding every metric to the wrapped factory.
+type droppedSpanFactory struct {
+	jaegermetric.Factory
+	dropped *atomic.Int64
+}
+
+func (f droppedSpanFactory) Counter(options jaegermetric.Options) jaegermetric.Counter {
+	counter := f.Factory.Counter(options)
+	if options.Name == "reporter_spans" && options.Tags["result"] == "dropped" {
+		return droppedSpanCounter{counter, f.dropped}
+	}
+	return counter
+}
+
+func (f droppedSpanFactory) Namespace(scope jaegermetric.NSOptions) jaegermetric.Factory {
+	return droppedSpanFactory{f.Factory.Namespace(scope), f.dropped}
+}
+
+type droppedSpanCounter struct {
+	jaegermetric.Counter
+	dropped *atomic.Int64
+}
+
+func (c droppedSpanCounter) Inc(delta int64) {
+	c.dropped.Add(delta)
+	c.Counter.Inc(delta)
+}
@@ -0,0 +1,76 @@
+package observability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/opentracing/opentracing-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
+	jaegermetric "github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/atomic"
+)
+
+func TestHealthAwareSampler(t *testing.T) {
+	var dropped atomic.Int64
+	factory := droppedSpanFactory{Factory: jaegermetric.NullFactory, dropped: &dropped}
+	metrics := jaeger.NewMetrics(factory, nil)
+
+	sampler := newHealthAwareSampler(
+		jaeger.NewConstSampler(true),
+		&dropped,
+		ProvideJaegerLogAdapter(log.NewNopLogger()),
+		10,
+		time.Hour,
+		0,
+	)
+	defer sampler.Close()
+
+	tracer, closer := jaeger.NewTracer("test", sampler, jaeger.NewNullReporter())
+	defer closer.Close()
+
+	span := tracer.StartSpan("foo")
+	assert.True(t, span.Context().(jaeger.SpanContext).IsSampled())
+	span.Finish()
+
+	metrics.ReporterDropped.Inc(11)
+	sampler.check()
+	assert.True(t, TracerDegraded(tracer))
+
+	span = tracer.StartSpan("foo")
+	assert.False(t, span.Context().(jaeger.SpanContext).IsSampled())
+	span.Finish()
+
+	metrics.ReporterDropped.Inc(1)
+	sampler.check()
+	assert.True(t, sampler.Degraded())
+
+	sampler.check()
+	assert.False(t, sampler.Degraded())
+	assert.False(t, TracerDegraded(opentracing.NoopTracer{}))
+}
+
+func TestProvideOpentracing_Degradation(t *testing.T) {
+	conf, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(`
+jaeger:
+  sampler:
+    type: 'const'
+    param: 1
+  degradation:
+    threshold: 100
+`)), yaml.Parser()))
+	tracer, cleanup, err := ProvideOpentracing(
+		config.AppName("foo"),
+		config.EnvTesting,
+		ProvideJaegerLogAdapter(log.NewNopLogger()),
+		conf,
+	)
+	assert.NoError(t, err)
+	defer cleanup()
+	_, ok := tracer.(*jaeger.Tracer).Sampler().(*HealthAwareSampler)
+	assert.True(t, ok)
+}
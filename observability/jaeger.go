@@ -3,16 +3,45 @@ package observability
 import (
 	"fmt"
 
+	"github.com/DoNewsCode/core/logging"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/uber/jaeger-client-go"
 )
 
-// JaegerLogAdapter is an adapter that bridges kitlog and Jaeger.
+// JaegerLogAdapter is an adapter that bridges kitlog and Jaeger. It implements
+// the jaeger.Logger and the jaeger-client-go/log.DebugLogger interfaces.
 type JaegerLogAdapter struct {
 	Logging log.Logger
 }
 
+// JaegerLogOption is the type of the options of NewJaegerLogAdapter.
+type JaegerLogOption func(adapter *JaegerLogAdapter)
+
+// WithMinLevel is an option that drops the logs below the level, one of
+// "debug", "info", "warn", "error" and "none", as understood by
+// logging.LevelFilter. Use "error" to suppress the Infof logs of the reporter
+// at steady state.
+func WithMinLevel(lvl string) JaegerLogOption {
+	return func(adapter *JaegerLogAdapter) {
+		adapter.Logging = level.NewFilter(adapter.Logging, logging.LevelFilter(lvl))
+	}
+}
+
+// NewJaegerLogAdapter returns a *JaegerLogAdapter writing to the logger.
+func NewJaegerLogAdapter(l log.Logger, opts ...JaegerLogOption) *JaegerLogAdapter {
+	adapter := &JaegerLogAdapter{Logging: l}
+	for _, f := range opts {
+		f(adapter)
+	}
+	return adapter
+}
+
+// Debugf implements jaeger's debug logger
+func (l JaegerLogAdapter) Debugf(msg string, args ...interface{}) {
+	level.Debug(l.Logging).Log("msg", fmt.Sprintf(msg, args...))
+}
+
 // Infof implements jaeger's logger
 func (l JaegerLogAdapter) Infof(msg string, args ...interface{}) {
 	level.Info(l.Logging).Log("msg", fmt.Sprintf(msg, args...))
@@ -25,5 +54,5 @@ func (l JaegerLogAdapter) Error(msg string) {
 
 // ProvideJaegerLogAdapter returns a valid jaeger.Logger.
 func ProvideJaegerLogAdapter(l log.Logger) jaeger.Logger {
-	return &JaegerLogAdapter{Logging: log.With(l, "tag", "observability")}
+	return NewJaegerLogAdapter(log.With(l, "tag", "observability"))
 }
@@ -0,0 +1,26 @@
+package observability
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+	jaegerlog "github.com/uber/jaeger-client-go/log"
+)
+
+func TestJaegerLogAdapter(t *testing.T) {
+	var buf bytes.Buffer
+	var adapter jaegerlog.DebugLogger = NewJaegerLogAdapter(log.NewLogfmtLogger(&buf))
+	adapter.Debugf("foo %d", 1)
+	adapter.Infof("bar %d", 2)
+	adapter.Error("baz")
+	assert.Equal(t, "level=debug msg=\"foo 1\"\nlevel=info msg=\"bar 2\"\nlevel=error msg=baz\n", buf.String())
+
+	buf.Reset()
+	adapter = NewJaegerLogAdapter(log.NewLogfmtLogger(&buf), WithMinLevel("error"))
+	adapter.Debugf("foo")
+	adapter.Infof("bar")
+	adapter.Error("baz")
+	assert.Equal(t, "level=error msg=baz\n", buf.String())
+}
@@ -1,13 +1,18 @@
 package observability
 
 import (
+	"errors"
 	"github.com/DoNewsCode/core/otkafka"
+	"github.com/DoNewsCode/core/otkafka/processor"
 	"sync"
 
+	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/otgorm"
 	"github.com/DoNewsCode/core/otredis"
+	"github.com/DoNewsCode/core/srvhttp"
 	"github.com/go-kit/kit/metrics"
 	"github.com/go-kit/kit/metrics/prometheus"
+	"github.com/gorilla/mux"
 	stdprometheus "github.com/prometheus/client_golang/prometheus"
 )
 
@@ -22,15 +27,106 @@ var his histogram
 // to the system. Note it has three labels: "module", "service", "method". If any label is missing,
 // the system will panic.
 func ProvideHistogramMetrics() metrics.Histogram {
+	return newHistogramMetrics(nil)
+}
+
+// provideHistogramMetrics is like ProvideHistogramMetrics, but with the buckets
+// read from "metrics.buckets".
+func provideHistogramMetrics(conf contract.ConfigAccessor) metrics.Histogram {
+	return newHistogramMetrics(histogramBuckets(conf))
+}
+
+func newHistogramMetrics(buckets []float64) metrics.Histogram {
 	his.once.Do(func() {
 		his.Histogram = prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
-			Name: "http_request_duration_seconds",
-			Help: "Total time spent serving requests.",
+			Name:    "http_request_duration_seconds",
+			Help:    "Total time spent serving requests.",
+			Buckets: buckets,
 		}, []string{"module", "service", "method"})
 	})
 	return &his
 }
 
+// histogramBuckets reads the histogram buckets from "metrics.buckets". It
+// returns nil, meaning prometheus.DefBuckets, if unset.
+func histogramBuckets(conf contract.ConfigAccessor) []float64 {
+	var buckets []float64
+	_ = conf.Unmarshal("metrics.buckets", &buckets)
+	return buckets
+}
+
+// ProvideHistogram registers a histogram with the registerer, and returns it as
+// a metrics.Histogram. Unless set in opts, the buckets are read from
+// "metrics.buckets", so that the latency buckets can be tuned per deployment.
+// If a histogram of the same name and labels is already registered, it is
+// reused, so that modules can share the metrics.
+func ProvideHistogram(
+	registerer stdprometheus.Registerer,
+	conf contract.ConfigAccessor,
+	opts stdprometheus.HistogramOpts,
+	labelNames ...string,
+) (metrics.Histogram, error) {
+	if opts.Buckets == nil {
+		opts.Buckets = histogramBuckets(conf)
+	}
+	hv := stdprometheus.NewHistogramVec(opts, labelNames)
+	if err := registerer.Register(hv); err != nil {
+		var are stdprometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		existing, ok := are.ExistingCollector.(*stdprometheus.HistogramVec)
+		if !ok {
+			return nil, err
+		}
+		hv = existing
+	}
+	return prometheus.NewHistogram(hv), nil
+}
+
+// ProvideCounter registers a counter with the registerer, and returns it as a
+// metrics.Counter. If a counter of the same name and labels is already
+// registered, it is reused.
+func ProvideCounter(
+	registerer stdprometheus.Registerer,
+	opts stdprometheus.CounterOpts,
+	labelNames ...string,
+) (metrics.Counter, error) {
+	cv := stdprometheus.NewCounterVec(opts, labelNames)
+	if err := registerer.Register(cv); err != nil {
+		var are stdprometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			return nil, err
+		}
+		existing, ok := are.ExistingCollector.(*stdprometheus.CounterVec)
+		if !ok {
+			return nil, err
+		}
+		cv = existing
+	}
+	return prometheus.NewCounter(cv), nil
+}
+
+// ApplyMetrics exposes the metrics collected by the gatherer at /metrics. Package
+// core already does so at http.metrics.path when serving HTTP, so it is only
+// needed on routers served elsewhere, such as a dedicated admin server.
+func ApplyMetrics(router *mux.Router, gatherer stdprometheus.Gatherer) {
+	srvhttp.ProvideMetricsRoute(router, gatherer, "/metrics")
+}
+
+// ProvideGatherer returns the default prometheus.Gatherer, where the metrics
+// provided by this package are registered. With a prometheus.Gatherer
+// available, package core exposes the metrics at the http.metrics.path.
+func ProvideGatherer() stdprometheus.Gatherer {
+	return stdprometheus.DefaultGatherer
+}
+
+// ProvideRegisterer returns the default prometheus.Registerer. Packages like
+// otetcd register their collectors with it when available.
+func ProvideRegisterer() stdprometheus.Registerer {
+	return stdprometheus.DefaultRegisterer
+}
+
 // ProvideGORMMetrics returns a *otgorm.Gauges that measures the connection info in databases.
 // It is meant to be consumed by the otgorm.Providers.
 func ProvideGORMMetrics() *otgorm.Gauges {
@@ -351,3 +447,24 @@ func ProvideKafkaWriterMetrics() *otkafka.WriterStats {
 		},
 	}
 }
+
+// ProvideKafkaProcessorMetrics returns a *processor.Metrics that measures the
+// messages handled by the kafka processor. It is meant to be consumed by the
+// processor.New.
+func ProvideKafkaProcessorMetrics() *processor.Metrics {
+	labels := []string{"topic"}
+	return &processor.Metrics{
+		Processed: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "kafka_processor_processed_count",
+			Help: "number of messages handled successfully",
+		}, labels),
+		Errors: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Name: "kafka_processor_error_count",
+			Help: "number of messages failed to handle",
+		}, labels),
+		Latency: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Name: "kafka_processor_handle_seconds",
+			Help: "time spent handling a message",
+		}, labels),
+	}
+}
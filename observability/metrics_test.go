@@ -0,0 +1,46 @@
+package observability
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/gorilla/mux"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMetrics(t *testing.T) {
+	registry := stdprometheus.NewRegistry()
+	conf, err := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte("metrics: {buckets: [0.1, 1]}")), yaml.Parser()))
+	assert.NoError(t, err)
+
+	counter, err := ProvideCounter(registry, stdprometheus.CounterOpts{Name: "foo_total", Help: "foo"}, "module")
+	assert.NoError(t, err)
+	counter.With("module", "bar").Add(2)
+	// Registering the same counter again reuses the first one.
+	counter, err = ProvideCounter(registry, stdprometheus.CounterOpts{Name: "foo_total", Help: "foo"}, "module")
+	assert.NoError(t, err)
+	counter.With("module", "bar").Add(1)
+
+	histogram, err := ProvideHistogram(registry, conf, stdprometheus.HistogramOpts{Name: "foo_seconds", Help: "foo"})
+	assert.NoError(t, err)
+	histogram.Observe(0.5)
+
+	_, err = ProvideHistogram(registry, conf, stdprometheus.HistogramOpts{Name: "foo_total", Help: "foo"}, "module")
+	assert.Error(t, err)
+
+	router := mux.NewRouter()
+	ApplyMetrics(router, registry)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := ioutil.ReadAll(recorder.Body)
+
+	assert.Contains(t, string(body), `foo_total{module="bar"} 3`)
+	assert.Contains(t, string(body), `foo_seconds_bucket{le="0.1"} 0`)
+	assert.Contains(t, string(body), `foo_seconds_bucket{le="1"} 1`)
+	assert.NotContains(t, string(body), `le="0.5"`)
+}
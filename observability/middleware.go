@@ -0,0 +1,179 @@
+package observability
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HTTPServerTracing returns a middleware that extracts the parent span from the
+// incoming headers, starts a server span and puts it into the request context.
+// The span is tagged with the method, url, route template (when used with a
+// gorilla/mux router) and status code.
+//
+// If the handler panics, the span is marked as an error and finished before the
+// panic continues to propagate, so a recovery middleware should be installed
+// outside of this one. Likewise, install metrics middlewares inside of this one
+// if they need the span in the context.
+//
+//	router.Use(observability.HTTPServerTracing(tracer))
+func HTTPServerTracing(tracer opentracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			operation := request.URL.Path
+			if route := mux.CurrentRoute(request); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					operation = tpl
+				}
+			}
+			parent, _ := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(request.Header))
+			span := tracer.StartSpan(fmt.Sprintf("HTTP %s %s", request.Method, operation), ext.RPCServerOption(parent))
+			defer span.Finish()
+
+			ext.Component.Set(span, "net/http")
+			ext.HTTPMethod.Set(span, request.Method)
+			ext.HTTPUrl.Set(span, request.URL.String())
+			span.SetTag("http.route", operation)
+
+			recorder := &statusRecorder{ResponseWriter: writer, status: http.StatusOK}
+			defer func() {
+				if r := recover(); r != nil {
+					ext.Error.Set(span, true)
+					span.LogFields(log.String("event", "panic"), log.Object("panic", r))
+					panic(r)
+				}
+				ext.HTTPStatusCode.Set(span, uint16(recorder.status))
+				if recorder.status >= http.StatusInternalServerError {
+					ext.Error.Set(span, true)
+				}
+			}()
+			next.ServeHTTP(recorder, request.WithContext(opentracing.ContextWithSpan(request.Context(), span)))
+		})
+	}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	n, err := s.ResponseWriter.Write(p)
+	s.written += n
+	return n, err
+}
+
+// Flush implements http.Flusher if the underlying writer supports it.
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying writer supports it, so that
+// connections can be upgraded, to websocket for example. As the response is then
+// written to the connection directly, the status is recorded as 101 Switching
+// Protocols.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the underlying http.ResponseWriter doesn't implement http.Hijacker")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		s.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// GRPCUnaryServerTracing returns a grpc.UnaryServerInterceptor that extracts the
+// parent span from the incoming metadata, starts a server span and puts it into
+// the context. The span is tagged with the full method and the status code.
+// Panics are handled the same way as HTTPServerTracing.
+//
+//	grpc.NewServer(grpc.ChainUnaryInterceptor(
+//		recovery,
+//		observability.GRPCUnaryServerTracing(tracer),
+//		grpc_prometheus.UnaryServerInterceptor,
+//	))
+func GRPCUnaryServerTracing(tracer opentracing.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		span, ctx := startGRPCServerSpan(ctx, tracer, info.FullMethod)
+		defer span.Finish()
+		defer finishGRPCServerSpan(span, &err)
+		return handler(ctx, req)
+	}
+}
+
+// GRPCStreamServerTracing is the stream counterpart of GRPCUnaryServerTracing.
+func GRPCStreamServerTracing(tracer opentracing.Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		span, ctx := startGRPCServerSpan(ss.Context(), tracer, info.FullMethod)
+		defer span.Finish()
+		defer finishGRPCServerSpan(span, &err)
+		return handler(srv, tracedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func startGRPCServerSpan(ctx context.Context, tracer opentracing.Tracer, method string) (opentracing.Span, context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	parent, _ := tracer.Extract(opentracing.HTTPHeaders, metadataCarrier(md))
+	span := tracer.StartSpan(method, ext.RPCServerOption(parent))
+	ext.Component.Set(span, "gRPC")
+	span.SetTag("grpc.method", method)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
+
+func finishGRPCServerSpan(span opentracing.Span, err *error) {
+	if r := recover(); r != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.String("event", "panic"), log.Object("panic", r))
+		panic(r)
+	}
+	span.SetTag("grpc.code", status.Code(*err).String())
+	if *err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.Error(*err))
+	}
+}
+
+type tracedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (t tracedServerStream) Context() context.Context {
+	return t.ctx
+}
+
+// metadataCarrier adapts metadata.MD to opentracing.TextMapReader.
+type metadataCarrier metadata.MD
+
+func (m metadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, vs := range m {
+		for _, v := range vs {
+			if err := handler(strings.ToLower(k), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package observability
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHTTPServerTracing(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("client")
+
+	router := mux.NewRouter()
+	router.Use(HTTPServerTracing(tracer))
+	router.HandleFunc("/foo/{id}", func(writer http.ResponseWriter, request *http.Request) {
+		assert.NotNil(t, opentracing.SpanFromContext(request.Context()))
+		writer.WriteHeader(http.StatusTeapot)
+	})
+	router.HandleFunc("/panic", func(writer http.ResponseWriter, request *http.Request) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/foo/1", nil)
+	tracer.Inject(parent.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Panics(t, func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	})
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "HTTP GET /foo/{id}", spans[0].OperationName)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, spans[0].ParentID)
+	assert.Equal(t, uint16(http.StatusTeapot), spans[0].Tag("http.status_code"))
+	assert.Equal(t, true, spans[1].Tag("error"))
+}
+
+func TestHTTPServerTracing_hijack(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	assert.Equal(t, http.StatusSwitchingProtocols, upgrade(t, HTTPServerTracing(tracer)(http.HandlerFunc(switchProtocols))))
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, uint16(http.StatusSwitchingProtocols), spans[0].Tag("http.status_code"))
+
+	_, _, err := (&statusRecorder{ResponseWriter: httptest.NewRecorder()}).Hijack()
+	assert.Error(t, err)
+}
+
+// switchProtocols hijacks the connection and answers 101 Switching Protocols,
+// the same way as websocket upgraders do.
+func switchProtocols(writer http.ResponseWriter, request *http.Request) {
+	conn, rw, err := writer.(http.Hijacker).Hijack()
+	if err != nil {
+		http.Error(writer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer conn.Close()
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+	rw.Flush()
+}
+
+// upgrade sends an upgrade request to the handler, and returns the status of
+// the response once the handler returned.
+func upgrade(t *testing.T, handler http.Handler) int {
+	t.Helper()
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		defer close(done)
+		handler.ServeHTTP(writer, request)
+	}))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return 0
+	}
+	defer conn.Close()
+	request, _ := http.NewRequest("GET", server.URL, nil)
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Upgrade", "test")
+	assert.NoError(t, request.Write(conn))
+	response, err := http.ReadResponse(bufio.NewReader(conn), request)
+	if !assert.NoError(t, err) {
+		return 0
+	}
+	response.Body.Close()
+	<-done
+	return response.StatusCode
+}
+
+func TestGRPCUnaryServerTracing(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("client")
+	md := metadata.MD{}
+	tracer.Inject(parent.Context(), opentracing.HTTPHeaders, metadataTextMap(md))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	interceptor := GRPCUnaryServerTracing(tracer)
+	info := &grpc.UnaryServerInfo{FullMethod: "/foo.Bar/Baz"}
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		assert.NotNil(t, opentracing.SpanFromContext(ctx))
+		return nil, errors.New("err")
+	})
+	assert.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "/foo.Bar/Baz", spans[0].OperationName)
+	assert.Equal(t, parent.Context().(mocktracer.MockSpanContext).SpanID, spans[0].ParentID)
+	assert.Equal(t, true, spans[0].Tag("error"))
+}
+
+type metadataTextMap metadata.MD
+
+func (m metadataTextMap) Set(key, val string) {
+	metadata.MD(m).Set(key, val)
+}
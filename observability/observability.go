@@ -17,21 +17,28 @@ Providers returns a set of providers available in package observability
 	Provides:
 		opentracing.Tracer
 		metrics.Histogram
+		prometheus.Gatherer
+		prometheus.Registerer
 */
 func Providers() di.Deps {
 	return di.Deps{
 		ProvideJaegerLogAdapter,
-		ProvideOpentracing,
-		ProvideHistogramMetrics,
+		provideTracer,
+		provideHistogramMetrics,
+		ProvideGatherer,
+		ProvideRegisterer,
 		ProvideGORMMetrics,
 		ProvideRedisMetrics,
 		ProvideKafkaReaderMetrics,
 		ProvideKafkaWriterMetrics,
+		ProvideKafkaProcessorMetrics,
 		provideConfig,
 	}
 }
 
 const sample = `
+tracing:
+  backend: jaeger
 jaeger:
   sampler:
     type: 'const'
@@ -40,6 +47,26 @@ jaeger:
     log:
       enable: false
     addr:
+    flushInterval: 1s
+  degradation:
+    threshold: 0
+    interval: 10s
+    samplingRate: 0.01
+zipkin:
+  serviceName:
+  sampler:
+    rate: 1
+  reporter:
+    url: http://127.0.0.1:9411/api/v2/spans
+metrics:
+  buckets: [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+otlp:
+  serviceName:
+  endpoint: 127.0.0.1:4317
+  insecure: false
+  headers: {}
+  sampler:
+    rate: 1
 `
 
 type configOut struct {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/DoNewsCode/core"
 	"github.com/DoNewsCode/core/config"
@@ -16,6 +17,7 @@ import (
 	"github.com/knadh/koanf/providers/rawbytes"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-client-go"
 	"gorm.io/gorm"
 )
 
@@ -32,6 +34,48 @@ func TestProvideOpentracing(t *testing.T) {
 	cleanup()
 }
 
+func TestJaegerConfiguration(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		yaml    string
+		sampler interface{}
+	}{
+		{
+			"default",
+			"",
+			&jaeger.ProbabilisticSampler{},
+		},
+		{
+			"probabilistic",
+			"jaeger: {sampler: {type: probabilistic, param: 0.5}}",
+			&jaeger.ProbabilisticSampler{},
+		},
+		{
+			"ratelimiting",
+			"jaeger: {sampler: {type: ratelimiting, param: 10}}",
+			&jaeger.RateLimitingSampler{},
+		},
+		{
+			"const",
+			"jaeger: {sampler: {type: const, param: 1}}",
+			&jaeger.ConstSampler{},
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			conf, err := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(c.yaml)), yaml.Parser()))
+			assert.NoError(t, err)
+			cfg := jaegerConfiguration(config.AppName("foo"), config.EnvTesting, conf)
+			sampler, err := cfg.Sampler.NewSampler(cfg.ServiceName, jaeger.NewNullMetrics())
+			assert.NoError(t, err)
+			assert.IsType(t, c.sampler, sampler)
+		})
+	}
+
+	conf, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(sample)), yaml.Parser()))
+	cfg := jaegerConfiguration(config.AppName("foo"), config.EnvTesting, conf)
+	assert.Equal(t, time.Second, cfg.Reporter.BufferFlushInterval)
+}
+
 func TestProvideHistogramMetrics(t *testing.T) {
 	Out := ProvideHistogramMetrics()
 	assert.NotNil(t, Out)
@@ -0,0 +1,169 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otbridge "go.opentelemetry.io/otel/bridge/opentracing"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+)
+
+const (
+	defaultOTLPEndpoint = "127.0.0.1:4317"
+	otlpShutdownTimeout = 5 * time.Second
+)
+
+type otlpConf struct {
+	ServiceName string            `json:"serviceName" yaml:"serviceName"`
+	Endpoint    string            `json:"endpoint" yaml:"endpoint"`
+	Insecure    bool              `json:"insecure" yaml:"insecure"`
+	Headers     map[string]string `json:"headers" yaml:"headers"`
+	Sampler     struct {
+		Rate *float64 `json:"rate" yaml:"rate"`
+	} `json:"sampler" yaml:"sampler"`
+}
+
+// ProvideOTLPTracer provides a opentracing.Tracer bridged to OpenTelemetry, so
+// that the spans of the opentracing instrumented packages are exported over OTLP
+// gRPC. The collector is at "otlp.endpoint" (127.0.0.1:4317 by default), dialed
+// without TLS if "otlp.insecure" is true, with the "otlp.headers" attached. The
+// service name is "otlp.serviceName", which defaults to the app name and the env
+// like ProvideOpentracing. "otlp.sampler.rate" is the ratio of the root spans
+// sampled, 1 by default.
+//
+// It is a drop-in replacement of ProvideOpentracing. With Providers, set
+// "tracing.backend" to "otlp" to use it.
+func ProvideOTLPTracer(
+	appName contract.AppName,
+	env contract.Env,
+	logger log.Logger,
+	conf contract.ConfigAccessor,
+) (opentracing.Tracer, func(), error) {
+	var oconf otlpConf
+	if err := conf.Unmarshal("otlp", &oconf); err != nil {
+		return nil, nil, fmt.Errorf("otlp configuration not valid: %w", err)
+	}
+	if oconf.ServiceName == "" {
+		oconf.ServiceName = fmt.Sprintf("%s.%s", appName, env)
+	}
+	if oconf.Endpoint == "" {
+		oconf.Endpoint = defaultOTLPEndpoint
+	}
+	rate := 1.0
+	if oconf.Sampler.Rate != nil {
+		rate = *oconf.Sampler.Rate
+	}
+
+	opts := []otlpgrpc.Option{otlpgrpc.WithEndpoint(oconf.Endpoint)}
+	if oconf.Insecure {
+		opts = append(opts, otlpgrpc.WithInsecure())
+	}
+	if len(oconf.Headers) > 0 {
+		opts = append(opts, otlpgrpc.WithHeaders(oconf.Headers))
+	}
+	exporter, err := otlp.NewExporter(context.Background(), otlpgrpc.NewDriver(opts...))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to initialize otlp exporter: %w", err)
+	}
+	tracer, closer := newBridgeTracer(exporter, oconf.ServiceName, rate, log.With(logger, "tag", "observability"))
+	return tracer, closer, nil
+}
+
+// newBridgeTracer returns a opentracing.Tracer creating OpenTelemetry spans,
+// exported in batches by the exporter. The spans are propagated with the W3C
+// trace context and baggage headers.
+func newBridgeTracer(exporter exporttrace.SpanExporter, serviceName string, rate float64, logger log.Logger) (opentracing.Tracer, func()) {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(rate))}),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+	)
+	bridge, _ := otbridge.NewTracerPair(provider.Tracer("github.com/DoNewsCode/core/observability"))
+	bridge.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	bridge.SetWarningHandler(func(msg string) {
+		level.Warn(logger).Log("msg", msg)
+	})
+	closer := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), otlpShutdownTimeout)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			level.Error(logger).Log("err", err)
+		}
+	}
+	return carrierTracer{bridge}, closer
+}
+
+// carrierTracer adapts the bridge, which only injects into and extracts from
+// opentracing.HTTPHeadersCarrier, to any carrier of the TextMap and HTTPHeaders
+// formats, such as the kafka headers and the gRPC metadata. It also passes the
+// ext.SpanKindEnum start tags as strings, the only type the bridge recognizes.
+type carrierTracer struct {
+	*otbridge.BridgeTracer
+}
+
+func (t carrierTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	var sso opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&sso)
+	}
+	if kind, ok := sso.Tags[string(ext.SpanKind)].(ext.SpanKindEnum); ok {
+		opts = append(opts, opentracing.Tag{Key: string(ext.SpanKind), Value: string(kind)})
+	}
+	return t.BridgeTracer.StartSpan(operationName, opts...)
+}
+
+func (t carrierTracer) Inject(sm opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return t.BridgeTracer.Inject(sm, format, carrier)
+	}
+	if _, ok := carrier.(opentracing.HTTPHeadersCarrier); ok {
+		return t.BridgeTracer.Inject(sm, opentracing.HTTPHeaders, carrier)
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	header := make(http.Header)
+	if err := t.BridgeTracer.Inject(sm, opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header)); err != nil {
+		return err
+	}
+	for k := range header {
+		writer.Set(strings.ToLower(k), header.Get(k))
+	}
+	return nil
+}
+
+func (t carrierTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format != opentracing.TextMap && format != opentracing.HTTPHeaders {
+		return t.BridgeTracer.Extract(format, carrier)
+	}
+	if _, ok := carrier.(opentracing.HTTPHeadersCarrier); ok {
+		return t.BridgeTracer.Extract(opentracing.HTTPHeaders, carrier)
+	}
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+	header := make(http.Header)
+	if err := reader.ForeachKey(func(key, val string) error {
+		header.Set(key, val)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return t.BridgeTracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+}
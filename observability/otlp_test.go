@@ -0,0 +1,75 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []*exporttrace.SpanSnapshot
+}
+
+func (m *memoryExporter) ExportSpans(ctx context.Context, spans []*exporttrace.SpanSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func (m *memoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func TestNewBridgeTracer(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer, cleanup := newBridgeTracer(exporter, "foo.testing", 1, log.NewNopLogger())
+
+	parent := tracer.StartSpan("parent", ext.SpanKindRPCClient)
+	parent.SetTag("http.method", "GET")
+	ext.Error.Set(parent, true)
+
+	carrier := opentracing.TextMapCarrier{}
+	assert.NoError(t, tracer.Inject(parent.Context(), opentracing.TextMap, carrier))
+	assert.NotEmpty(t, carrier["traceparent"])
+	remote, err := tracer.Extract(opentracing.TextMap, carrier)
+	assert.NoError(t, err)
+	child := tracer.StartSpan("child", opentracing.ChildOf(remote))
+	child.Finish()
+	parent.Finish()
+	cleanup()
+
+	assert.Len(t, exporter.spans, 2)
+	spans := make(map[string]*exporttrace.SpanSnapshot)
+	for _, span := range exporter.spans {
+		spans[span.Name] = span
+	}
+	assert.Equal(t, trace.SpanKindClient, spans["parent"].SpanKind)
+	assert.Equal(t, codes.Error, spans["parent"].StatusCode)
+	assert.Contains(t, spans["parent"].Attributes, label.String("http.method", "GET"))
+	assert.Equal(t, spans["parent"].SpanContext.TraceID, spans["child"].SpanContext.TraceID)
+	serviceName, _ := spans["parent"].Resource.LabelSet().Value("service.name")
+	assert.Equal(t, "foo.testing", serviceName.AsString())
+}
+
+func TestProvideOTLPTracer(t *testing.T) {
+	conf := config.MapAdapter{"otlp": map[string]interface{}{
+		"endpoint": "127.0.0.1:1",
+		"insecure": true,
+	}}
+	tracer, cleanup, err := ProvideOTLPTracer(config.AppName("foo"), config.EnvTesting, log.NewNopLogger(), conf)
+	assert.NoError(t, err)
+	assert.NotNil(t, tracer)
+	cleanup()
+}
@@ -4,43 +4,138 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/go-kit/kit/log"
 	"github.com/opentracing/opentracing-go"
 	"github.com/uber/jaeger-client-go"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	jaegermetric "github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/atomic"
 )
 
-// ProvideOpentracing provides a opentracing.Tracer.
+type degradationConf struct {
+	Threshold    int64           `json:"threshold" yaml:"threshold"`
+	Interval     config.Duration `json:"interval" yaml:"interval"`
+	SamplingRate float64         `json:"samplingRate" yaml:"samplingRate"`
+}
+
+// defaultSamplingRate is the rate of the probabilistic sampler used when no
+// sampler is configured.
+const defaultSamplingRate = 0.1
+
+// jaegerConfiguration reads the jaeger configuration.
+func jaegerConfiguration(appName contract.AppName, env contract.Env, conf contract.ConfigAccessor) jaegercfg.Configuration {
+	sampler := &jaegercfg.SamplerConfig{
+		Type:  conf.String("jaeger.sampler.type"),
+		Param: conf.Float64("jaeger.sampler.param"),
+	}
+	if sampler.Type == "" {
+		sampler.Type = jaeger.SamplerTypeProbabilistic
+		sampler.Param = defaultSamplingRate
+	}
+	var flushInterval config.Duration
+	_ = conf.Unmarshal("jaeger.reporter.flushInterval", &flushInterval)
+	return jaegercfg.Configuration{
+		ServiceName: fmt.Sprintf("%s.%s", appName, env),
+		Sampler:     sampler,
+		Reporter: &jaegercfg.ReporterConfig{
+			LogSpans:            conf.Bool("jaeger.reporter.log"),
+			LocalAgentHostPort:  conf.String("jaeger.reporter.addr"),
+			BufferFlushInterval: flushInterval.Duration,
+		},
+	}
+}
+
+// tracerIn is the injection parameter for provideTracer.
+type tracerIn struct {
+	di.In
+
+	AppName      contract.AppName
+	Env          contract.Env
+	JaegerLogger jaeger.Logger
+	Logger       log.Logger
+	Conf         contract.ConfigAccessor
+}
+
+// provideTracer provides the opentracing.Tracer of the backend configured by
+// "tracing.backend", one of "jaeger" (the default), "zipkin" and "otlp".
+func provideTracer(in tracerIn) (opentracing.Tracer, func(), error) {
+	switch backend := in.Conf.String("tracing.backend"); backend {
+	case "", "jaeger":
+		return ProvideOpentracing(in.AppName, in.Env, in.JaegerLogger, in.Conf)
+	case "zipkin":
+		return ProvideZipkinTracer(in.AppName, in.Env, in.Logger, in.Conf)
+	case "otlp":
+		return ProvideOTLPTracer(in.AppName, in.Env, in.Logger, in.Conf)
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing backend %q", backend)
+	}
+}
+
+// ProvideOpentracing provides a opentracing.Tracer backed by jaeger.
+//
+// The sampler is configured by "jaeger.sampler.type", one of "const",
+// "probabilistic", "ratelimiting" and "remote", and "jaeger.sampler.param". If
+// the type is unset, a probabilistic sampler keeping 10% of the traces is used.
+// The spans are reported to the agent at "jaeger.reporter.addr", and flushed
+// every "jaeger.reporter.flushInterval" (1s by default).
+//
+// If "jaeger.degradation.threshold" is greater than zero, the sampler is wrapped
+// by a HealthAwareSampler. When more spans than the threshold are dropped by the
+// reporter within "jaeger.degradation.interval" (10s by default), the tracer
+// only keeps "jaeger.degradation.samplingRate" (0.01 by default) of the traces
+// it would otherwise sample, until an interval passes without drops. Use
+// TracerDegraded to inspect the state.
 func ProvideOpentracing(
 	appName contract.AppName,
 	env contract.Env,
 	log jaeger.Logger,
 	conf contract.ConfigAccessor,
 ) (opentracing.Tracer, func(), error) {
-	cfg := jaegercfg.Configuration{
-		ServiceName: fmt.Sprintf("%s.%s", appName, env),
-		Sampler: &jaegercfg.SamplerConfig{
-			Type:  conf.String("jaeger.sampler.type"),
-			Param: conf.Float64("jaeger.sampler.param"),
-		},
-		Reporter: &jaegercfg.ReporterConfig{
-			LogSpans:           conf.Bool("jaeger.reporter.log"),
-			LocalAgentHostPort: conf.String("jaeger.reporter.addr"),
-		},
-	}
+	cfg := jaegerConfiguration(appName, env, conf)
 	// Example logger and metrics factory. Use github.com/uber/jaeger-client-go/log
 	// and github.com/uber/jaeger-lib/metrics respectively to bind to real logging and metrics
 	// frameworks.
 	jLogger := log
-	jMetricsFactory := jaegermetric.NullFactory
+	var jMetricsFactory jaegermetric.Factory = jaegermetric.NullFactory
+
+	options := []jaegercfg.Option{jaegercfg.Logger(jLogger)}
+
+	var degradation degradationConf
+	_ = conf.Unmarshal("jaeger.degradation", &degradation)
+	if degradation.Threshold > 0 {
+		if degradation.Interval.IsZero() {
+			degradation.Interval = config.Duration{Duration: defaultDegradationInterval}
+		}
+		if degradation.SamplingRate <= 0 {
+			degradation.SamplingRate = defaultDegradationSamplingRate
+		}
+		var dropped atomic.Int64
+		jMetricsFactory = droppedSpanFactory{Factory: jMetricsFactory, dropped: &dropped}
+		sampler, err := cfg.Sampler.NewSampler(cfg.ServiceName, jaeger.NewMetrics(jMetricsFactory, nil))
+		if err != nil {
+			log.Error(fmt.Sprintf("Could not initialize jaeger sampler: %s", err.Error()))
+			return nil, nil, err
+		}
+		options = append(options, jaegercfg.Sampler(newHealthAwareSampler(
+			sampler,
+			&dropped,
+			jLogger,
+			degradation.Threshold,
+			degradation.Interval.Duration,
+			degradation.SamplingRate,
+		)))
+	}
+	options = append(options, jaegercfg.Metrics(jMetricsFactory))
 
 	// Initialize tracer with a logger and a metrics factory
 	var (
 		canceler io.Closer
 		err      error
 	)
-	tracer, canceler, err := cfg.NewTracer(jaegercfg.Logger(jLogger), jaegercfg.Metrics(jMetricsFactory))
+	tracer, canceler, err := cfg.NewTracer(options...)
 	if err != nil {
 		log.Error(fmt.Sprintf("Could not initialize jaeger tracer: %s", err.Error()))
 		return nil, nil, err
@@ -0,0 +1,81 @@
+package observability
+
+import (
+	"fmt"
+	stdlog "log"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	zipkinot "github.com/openzipkin-contrib/zipkin-go-opentracing"
+	"github.com/openzipkin/zipkin-go"
+	zipkinhttp "github.com/openzipkin/zipkin-go/reporter/http"
+)
+
+const defaultZipkinURL = "http://127.0.0.1:9411/api/v2/spans"
+
+type zipkinConf struct {
+	ServiceName string `json:"serviceName" yaml:"serviceName"`
+	Sampler     struct {
+		Rate *float64 `json:"rate" yaml:"rate"`
+	} `json:"sampler" yaml:"sampler"`
+	Reporter struct {
+		URL string `json:"url" yaml:"url"`
+	} `json:"reporter" yaml:"reporter"`
+}
+
+// ProvideZipkinTracer provides a opentracing.Tracer backed by zipkin. The spans
+// are sent to "zipkin.reporter.url" over HTTP, under the service name
+// "zipkin.serviceName", which defaults to the app name and the env like
+// ProvideOpentracing. "zipkin.sampler.rate" is the ratio of the traces sampled,
+// 1 by default.
+//
+// It is a drop-in replacement of ProvideOpentracing. With Providers, set
+// "tracing.backend" to "zipkin" to use it.
+func ProvideZipkinTracer(
+	appName contract.AppName,
+	env contract.Env,
+	logger log.Logger,
+	conf contract.ConfigAccessor,
+) (opentracing.Tracer, func(), error) {
+	var zconf zipkinConf
+	if err := conf.Unmarshal("zipkin", &zconf); err != nil {
+		return nil, nil, fmt.Errorf("zipkin configuration not valid: %w", err)
+	}
+	if zconf.ServiceName == "" {
+		zconf.ServiceName = fmt.Sprintf("%s.%s", appName, env)
+	}
+	if zconf.Reporter.URL == "" {
+		zconf.Reporter.URL = defaultZipkinURL
+	}
+	rate := 1.0
+	if zconf.Sampler.Rate != nil {
+		rate = *zconf.Sampler.Rate
+	}
+	sampler, err := zipkin.NewCountingSampler(rate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("zipkin sampler not valid: %w", err)
+	}
+	endpoint, err := zipkin.NewEndpoint(zconf.ServiceName, "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("zipkin endpoint not valid: %w", err)
+	}
+
+	logger = log.With(logger, "tag", "observability")
+	reporter := zipkinhttp.NewReporter(
+		zconf.Reporter.URL,
+		zipkinhttp.Logger(stdlog.New(log.NewStdlibAdapter(level.Error(logger)), "", 0)),
+	)
+	nativeTracer, err := zipkin.NewTracer(reporter, zipkin.WithLocalEndpoint(endpoint), zipkin.WithSampler(sampler))
+	if err != nil {
+		_ = reporter.Close()
+		return nil, nil, fmt.Errorf("unable to initialize zipkin tracer: %w", err)
+	}
+	closer := func() {
+		if err := reporter.Close(); err != nil {
+			level.Error(logger).Log("err", err)
+		}
+	}
+	return zipkinot.Wrap(nativeTracer), closer, nil
+}
@@ -0,0 +1,61 @@
+package observability
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvideZipkinTracer(t *testing.T) {
+	bodies := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		body, _ := ioutil.ReadAll(request.Body)
+		bodies <- string(body)
+		writer.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	conf := config.MapAdapter{"zipkin": map[string]interface{}{
+		"reporter": map[string]interface{}{"url": server.URL},
+	}}
+	tracer, cleanup, err := ProvideZipkinTracer(config.AppName("foo"), config.EnvTesting, log.NewNopLogger(), conf)
+	assert.NoError(t, err)
+	tracer.StartSpan("zipkin-test").Finish()
+	cleanup()
+
+	body := <-bodies
+	assert.Contains(t, body, "zipkin-test")
+	assert.Contains(t, body, "foo.testing")
+}
+
+func TestProvideTracer(t *testing.T) {
+	conf, _ := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(sample)), yaml.Parser()))
+	in := tracerIn{
+		AppName:      config.AppName("foo"),
+		Env:          config.EnvTesting,
+		JaegerLogger: ProvideJaegerLogAdapter(log.NewNopLogger()),
+		Logger:       log.NewNopLogger(),
+		Conf:         conf,
+	}
+	tracer, cleanup, err := provideTracer(in)
+	assert.NoError(t, err)
+	assert.NotNil(t, tracer)
+	cleanup()
+
+	in.Conf = config.MapAdapter{"tracing.backend": "zipkin"}
+	tracer, cleanup, err = provideTracer(in)
+	assert.NoError(t, err)
+	assert.NotNil(t, tracer)
+	cleanup()
+
+	in.Conf = config.MapAdapter{"tracing.backend": "unknown"}
+	_, _, err = provideTracer(in)
+	assert.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package otetcd
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"time"
 
@@ -11,6 +14,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/opentracing-contrib/go-grpc"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.etcd.io/etcd/client/v3"
 	"google.golang.org/grpc"
 )
@@ -22,10 +26,16 @@ Providers returns a set of dependencies including the Maker, the default *client
 		contract.ConfigAccessor
 		EtcdConfigInterceptor `optional:"true"`
 		opentracing.Tracer    `optional:"true"`
+		SVIDSource            `optional:"true"`
+		prometheus.Registerer `optional:"true"`
 	Provide:
 		Maker
 		Factory
 		*clientv3.Client
+
+If a prometheus.Registerer is available, the number of endpoints, the
+reachability and the time of the last successful status call of every client
+are reported.
 */
 func Providers() []interface{} {
 	return []interface{}{provideFactory, provideDefaultClient, provideConfig}
@@ -34,8 +44,24 @@ func Providers() []interface{} {
 // EtcdConfigInterceptor is an injector type hint that allows user to do
 // last minute modification to etcd configurations. This is useful when some
 // configuration can not be expressed in yaml/json. For example, the *tls.Config.
+// Note the *tls.Config can also be built from the certFile, keyFile and caFile
+// configurations. If both are present, the interceptor wins.
 type EtcdConfigInterceptor func(name string, options *clientv3.Config)
 
+// SVIDSource provides the client certificates for mutual TLS, typically X.509
+// SVIDs obtained from a SPIFFE workload API. The source is expected to rotate
+// the certificate by itself, and GetClientCertificate should always return the
+// current one.
+//
+// When a SVIDSource is provided, the certificate is fetched on every TLS
+// handshake, so rotated certificates are used for new connections without a
+// restart. Established connections keep the certificate they were created with
+// until they reconnect. The server is still verified with the caFile (or the
+// system pool), and the interceptor runs last, so it can override everything.
+type SVIDSource interface {
+	GetClientCertificate() (*tls.Certificate, error)
+}
+
 // Maker is models Factory
 type Maker interface {
 	Make(name string) (*clientv3.Client, error)
@@ -45,6 +71,8 @@ type Maker interface {
 // specific configuration entry.
 type Factory struct {
 	*di.Factory
+	conf     contract.ConfigAccessor
+	reloader *reloader
 }
 
 // Make creates *clientv3.Client using a specific configuration entry.
@@ -56,6 +84,100 @@ func (r Factory) Make(name string) (*clientv3.Client, error) {
 	return client.(*clientv3.Client), nil
 }
 
+// MakeContext is like Make, but gives up waiting for the client once the
+// context is done. This bounds the time spent in a blocking dial, for example
+// when the dialTimeout is set, or a grpc.WithBlock option is added by the
+// interceptor, so that a hung etcd doesn't block the boot indefinitely.
+//
+// Note the context is not used as the clientv3.Config.Context, as the client
+// would otherwise be closed with the context. The dial abandoned by
+// MakeContext carries on in the background, and its client is cached for the
+// next Make if it succeeds.
+func (r Factory) MakeContext(ctx context.Context, name string) (*clientv3.Client, error) {
+	type result struct {
+		client *clientv3.Client
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		client, err := r.Make(name)
+		ch <- result{client, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.client, res.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("unable to create etcd client %s: %w", name, ctx.Err())
+	}
+}
+
+// Names returns the sorted names of the clients configured under "etcd". The
+// "default" client can be made without configuration, so it is only listed if
+// configured.
+func (r Factory) Names() []string {
+	return internal.ConfiguredNames(r.conf, "etcd")
+}
+
+// Connections returns the sorted names of the clients currently created by the
+// factory.
+func (r Factory) Connections() []string {
+	return internal.CreatedNames(r.Factory)
+}
+
+// CloseConn closes the client of the given name and removes it from the
+// factory, leaving the other clients intact. The next Make creates a new client,
+// for example after its configuration changed or its cluster recovered. It
+// returns the error of closing the client, and nil if no client of that name
+// was created.
+func (r Factory) CloseConn(name string) error {
+	pair, ok := r.Factory.Evict(name)
+	if !ok {
+		return nil
+	}
+	err := pair.Conn.(*clientv3.Client).Close()
+	if pair.Closer != nil {
+		pair.Closer()
+	}
+	// A client closes its context, which is reported as canceled.
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return fmt.Errorf("unable to close etcd %s: %w", name, err)
+	}
+	return nil
+}
+
+// Reload unmarshals the etcd configuration again, and closes the clients whose
+// configuration changed. They are reconstructed on the next Make. Clients whose
+// configuration is unchanged are kept, so in-flight calls are not disrupted.
+//
+// Reload is called on every events.OnReload if a contract.Dispatcher is
+// available. Call it explicitly if the configuration source doesn't dispatch
+// such events.
+func (r Factory) Reload() error {
+	if r.reloader == nil {
+		r.Factory.Close()
+		return nil
+	}
+	return r.reloader.reload()
+}
+
+// CheckHealth makes sure the client of the given name is connected, by asking
+// the status of its first endpoint. It respects the deadline of the context, so
+// it can be used in readiness probes.
+func (r Factory) CheckHealth(ctx context.Context, name string) error {
+	client, err := r.Make(name)
+	if err != nil {
+		return fmt.Errorf("etcd %s unhealthy: %w", name, err)
+	}
+	endpoints := client.Endpoints()
+	if len(endpoints) == 0 {
+		return fmt.Errorf("etcd %s unhealthy: %w", name, clientv3.ErrNoAvailableEndpoints)
+	}
+	if _, err := client.Status(ctx, endpoints[0]); err != nil {
+		return fmt.Errorf("etcd %s unhealthy: %w", name, err)
+	}
+	return nil
+}
+
 // factoryIn is the injection parameter for provideFactory.
 type factoryIn struct {
 	di.In
@@ -65,6 +187,8 @@ type factoryIn struct {
 	Interceptor EtcdConfigInterceptor `optional:"true"`
 	Tracer      opentracing.Tracer    `optional:"true"`
 	Dispatcher  contract.Dispatcher   `optional:"true"`
+	SVIDSource  SVIDSource            `optional:"true"`
+	Registerer  prometheus.Registerer `optional:"true"`
 }
 
 // FactoryOut is the result of Provide.
@@ -78,7 +202,7 @@ type FactoryOut struct {
 // provideFactory creates Factory. It is a valid
 // dependency for package core.
 func provideFactory(p factoryIn) (FactoryOut, func()) {
-
+	var reload *reloader
 	factory := di.NewFactory(func(name string) (di.Pair, error) {
 		var (
 			conf Option
@@ -86,9 +210,17 @@ func provideFactory(p factoryIn) (FactoryOut, func()) {
 		if err := p.Conf.Unmarshal(fmt.Sprintf("etcd.%s", name), &conf); err != nil {
 			return di.Pair{}, fmt.Errorf("etcd configuration %s not valid: %w", name, err)
 		}
+		reload.record(name, conf)
 		if len(conf.Endpoints) == 0 {
 			conf.Endpoints = envDefaultEtcdAddrs
 		}
+		tlsConfig, err := conf.tlsConfig()
+		if err != nil {
+			return di.Pair{}, fmt.Errorf("etcd configuration %s not valid: %w", name, err)
+		}
+		if p.SVIDSource != nil {
+			tlsConfig = withSVIDSource(tlsConfig, p.SVIDSource)
+		}
 		co := clientv3.Config{
 			Endpoints:            conf.Endpoints,
 			AutoSyncInterval:     duration(conf.AutoSyncInterval),
@@ -97,7 +229,7 @@ func provideFactory(p factoryIn) (FactoryOut, func()) {
 			DialKeepAliveTimeout: duration(conf.DialKeepAliveTimeout),
 			MaxCallSendMsgSize:   conf.MaxCallSendMsgSize,
 			MaxCallRecvMsgSize:   conf.MaxCallRecvMsgSize,
-			TLS:                  conf.TLS,
+			TLS:                  tlsConfig,
 			Username:             conf.Username,
 			Password:             conf.Password,
 			RejectOldCluster:     conf.RejectOldCluster,
@@ -109,28 +241,63 @@ func provideFactory(p factoryIn) (FactoryOut, func()) {
 		if p.Tracer != nil {
 			co.DialOptions = append(
 				co.DialOptions,
-				grpc.WithUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(p.Tracer)),
-				grpc.WithStreamInterceptor(otgrpc.OpenTracingStreamClientInterceptor(p.Tracer)),
+				grpc.WithChainUnaryInterceptor(otgrpc.OpenTracingClientInterceptor(p.Tracer)),
+				grpc.WithChainStreamInterceptor(otgrpc.OpenTracingStreamClientInterceptor(p.Tracer)),
 			)
 		}
+		co.DialOptions = append(co.DialOptions, conf.retryDialOptions()...)
 		if p.Interceptor != nil {
 			p.Interceptor(name, &co)
 		}
-		client, _ := clientv3.New(co)
+		client, err := clientv3.New(co)
+		if err != nil {
+			return di.Pair{}, fmt.Errorf("unable to create etcd client %s: %w", name, err)
+		}
 		return di.Pair{
 			Conn: client,
 			Closer: func() {
 				_ = client.Close()
 			},
 		}, nil
-	})
-	etcdFactory := Factory{factory}
-	etcdFactory.SubscribeReloadEventFrom(p.Dispatcher)
+	}, di.WithValidator(func(conn interface{}) bool {
+		// A closed client is rebuilt, without a round trip. Other failures are
+		// left to the client, which reconnects on its own.
+		return conn.(*clientv3.Client).Ctx().Err() == nil
+	}))
+	reload = newReloader(p.Conf, factory)
+	reload.subscribe(p.Dispatcher)
+	etcdFactory := Factory{Factory: factory, conf: p.Conf, reloader: reload}
 	out := FactoryOut{
 		Maker:   etcdFactory,
 		Factory: etcdFactory,
 	}
-	return out, factory.Close
+	if p.Registerer == nil {
+		return out, factory.Close
+	}
+	collector := newCollector(etcdFactory, defaultStatusTimeout)
+	if err := p.Registerer.Register(collector); err != nil {
+		_ = p.Logger.Log("msg", "unable to register etcd metrics", "err", err)
+		return out, factory.Close
+	}
+	return out, func() {
+		p.Registerer.Unregister(collector)
+		factory.Close()
+	}
+}
+
+// withSVIDSource returns a copy of the tls.Config, which takes the client
+// certificates from the source.
+func withSVIDSource(tlsConfig *tls.Config, source SVIDSource) *tls.Config {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	tlsConfig.Certificates = nil
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		return source.GetClientCertificate()
+	}
+	return tlsConfig
 }
 
 func provideDefaultClient(maker Maker) (*clientv3.Client, error) {
@@ -159,6 +326,10 @@ func provideConfig() configOut {
 							MaxCallSendMsgSize:   0,
 							MaxCallRecvMsgSize:   0,
 							TLS:                  nil,
+							CertFile:             "",
+							KeyFile:              "",
+							CAFile:               "",
+							InsecureSkipVerify:   false,
 							Username:             "",
 							Password:             "",
 							RejectOldCluster:     false,
@@ -166,6 +337,8 @@ func provideConfig() configOut {
 							Context:              nil,
 							LogConfig:            nil,
 							PermitWithoutStream:  false,
+							MaxRetries:           0,
+							RetryBackoff:         config.Duration{},
 						},
 					},
 				},
@@ -1,9 +1,12 @@
 package otetcd
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/DoNewsCode/core"
 	"github.com/DoNewsCode/core/config"
@@ -11,6 +14,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc"
 	"gopkg.in/yaml.v3"
 )
 
@@ -66,6 +70,228 @@ func TestProvideFactory(t *testing.T) {
 	cleanup()
 }
 
+func TestFactory_Names(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default":     {Endpoints: envDefaultEtcdAddrs},
+			"alternative": {Endpoints: envDefaultEtcdAddrs},
+			"unused":      {Endpoints: envDefaultEtcdAddrs},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	assert.Equal(t, []string{"alternative", "default", "unused"}, out.Factory.Names())
+	assert.Empty(t, out.Factory.Connections())
+	_, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	_, err = out.Factory.Make("alternative")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alternative", "default"}, out.Factory.Connections())
+}
+
+func TestFactory_CloseConn(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default":     {Endpoints: envDefaultEtcdAddrs},
+			"alternative": {Endpoints: envDefaultEtcdAddrs},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	def, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	alt, err := out.Factory.Make("alternative")
+	assert.NoError(t, err)
+
+	assert.NoError(t, out.Factory.CloseConn("default"))
+	assert.NoError(t, out.Factory.CloseConn("default"))
+	assert.Error(t, def.Ctx().Err())
+	assert.NoError(t, alt.Ctx().Err())
+	assert.Equal(t, []string{"alternative"}, out.Factory.Connections())
+
+	remade, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.NotSame(t, def, remade)
+	assert.NoError(t, remade.Ctx().Err())
+}
+
+func TestFactory_validate(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {Endpoints: envDefaultEtcdAddrs},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	def, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	again, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.Same(t, def, again)
+
+	// A closed client is replaced.
+	assert.NoError(t, def.Close())
+	again, err = out.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.NotSame(t, def, again)
+	assert.NoError(t, again.Ctx().Err())
+}
+
+func TestProvideFactory_clientError(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints: envDefaultEtcdAddrs,
+			},
+		}},
+		Logger: log.NewNopLogger(),
+		Interceptor: func(name string, options *clientv3.Config) {
+			options.Endpoints = nil
+		},
+	})
+	defer cleanup()
+	client, err := out.Factory.Make("default")
+	assert.ErrorIs(t, err, clientv3.ErrNoAvailableEndpoints)
+	assert.Contains(t, err.Error(), "default")
+	assert.Nil(t, client)
+
+	_, err = provideDefaultClient(out.Maker)
+	assert.Error(t, err)
+}
+
+func TestFactory_MakeContext(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints: envDefaultEtcdAddrs,
+			},
+			"unreachable": {
+				Endpoints:   []string{"127.0.0.1:1"},
+				DialTimeout: config.Duration{Duration: 5 * time.Second},
+			},
+		}},
+		Logger: log.NewNopLogger(),
+		Interceptor: func(name string, options *clientv3.Config) {
+			options.DialOptions = append(options.DialOptions, grpc.WithBlock())
+		},
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	client, err := out.Factory.MakeContext(ctx, "default")
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	_, err = out.Factory.MakeContext(ctx, "unreachable")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(begin)), int64(time.Second))
+}
+
+func TestFactory_Reload(t *testing.T) {
+	conf := config.MapAdapter{"etcd": map[string]Option{
+		"default": {
+			Endpoints: envDefaultEtcdAddrs,
+		},
+		"alternative": {
+			Endpoints: envDefaultEtcdAddrs,
+		},
+	}}
+	out, cleanup := provideFactory(factoryIn{
+		Conf:   conf,
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	def, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	alt, err := out.Factory.Make("alternative")
+	assert.NoError(t, err)
+
+	conf["etcd"] = map[string]Option{
+		"default": {
+			Endpoints: envDefaultEtcdAddrs,
+		},
+		"alternative": {
+			Endpoints:          envDefaultEtcdAddrs,
+			MaxCallSendMsgSize: 1024 * 1024,
+		},
+	}
+	assert.NoError(t, out.Factory.Reload())
+
+	newDef, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.Same(t, def, newDef)
+	newAlt, err := out.Factory.Make("alternative")
+	assert.NoError(t, err)
+	assert.NotSame(t, alt, newAlt)
+}
+
+func TestFactory_CheckHealth(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints: envDefaultEtcdAddrs,
+			},
+			"unreachable": {
+				Endpoints: []string{"127.0.0.1:1"},
+			},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, out.Factory.CheckHealth(ctx, "default"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := out.Factory.CheckHealth(ctx, "unreachable")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unreachable")
+}
+
+type svidSource struct {
+	cert *tls.Certificate
+}
+
+func (s svidSource) GetClientCertificate() (*tls.Certificate, error) {
+	return s.cert, nil
+}
+
+func TestProvideFactory_SVIDSource(t *testing.T) {
+	var captured *tls.Config
+	source := svidSource{cert: &tls.Certificate{}}
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints:          envDefaultEtcdAddrs,
+				InsecureSkipVerify: true,
+			},
+		}},
+		Logger:     log.NewNopLogger(),
+		SVIDSource: source,
+		Interceptor: func(name string, options *clientv3.Config) {
+			captured = options.TLS
+		},
+	})
+	defer cleanup()
+	_, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+
+	assert.True(t, captured.InsecureSkipVerify)
+	cert, err := captured.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.NoError(t, err)
+	assert.Same(t, source.cert, cert)
+}
+
 func Test_provideConfig(t *testing.T) {
 	conf := provideConfig()
 	_, err := yaml.Marshal(conf.Config)
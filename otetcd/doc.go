@@ -9,16 +9,22 @@ package otetcd exports the configuration in the following format:
 	etcd:
 	  default:
         autoSyncIntervalSecond: 0
+        caFile: ""
+        certFile: ""
         dialKeepAliveTimeSecond: 0
         dialKeepAliveTimeoutSecond: 0
         dialTimeoutSecond: 0
         endpoints:
         - 127.0.0.1:2379
+        insecureSkipVerify: false
+        keyFile: ""
         maxCallRecvMsgSize: 0
         maxCallSendMsgSize: 0
+        maxRetries: 0
         password: ""
         permitWithoutStream: false
         rejectOldCluster: false
+        retryBackoff: 0s
         username: ""
 
 Add the etcd dependency to core:
@@ -39,5 +45,61 @@ otetcd.Maker to factory a *clientv3.Client with a specific configuration entry.
 		client, err := maker.Make("default")
 		// do something with client
 	})
+
+Hot Reload
+
+On every configuration reload, the clients whose configuration changed are
+closed, and reconstructed on the next Make. Other clients are kept untouched.
+If the configuration source doesn't dispatch reload events, call Factory.Reload
+explicitly:
+
+	err := factory.Reload()
+
+Retries
+
+Calls may fail transiently during a leader change. Set maxRetries to retry the
+calls failed with a transient error, waiting retryBackoff between two attempts.
+By default, maxRetries is zero and nothing is retried on top of clientv3.
+
+Mutual TLS
+
+The TLS configuration is built from certFile, keyFile and caFile. In zero-trust
+deployments where certificates are issued by a SPIFFE workload API, provide a
+SVIDSource instead, and the certificates are rotated without restarts:
+
+	c.Provide(di.Deps{func() otetcd.SVIDSource { return source }})
+
+Health Check
+
+Factory.CheckHealth confirms a client is connected, for example in a readiness
+probe:
+
+	err := factory.CheckHealth(ctx, "default")
+
+Distributed Lock
+
+To coordinate work across instances, create a Mutex from the Factory:
+
+	mutex, err := factory.NewMutex("default", "/my-lock", 10*time.Second)
+	if err := mutex.Lock(ctx); err == nil {
+		defer mutex.Unlock(ctx)
+		// do something exclusively
+	}
+
+Watch
+
+Watch wraps the watch of clientv3, and creates a span for every batch of events:
+
+	for event := range otetcd.Watch(ctx, client, "/config", clientv3.WithPrefix()) {
+		// do something with event.Events
+	}
+
+Large Transactions
+
+etcd limits the number of operations in a single transaction (--max-txn-ops, 128
+by default). ChunkedTxn splits the operations into chunks and commits them one
+by one. Note the operations are only atomic within a chunk.
+
+	err := otetcd.ChunkedTxn(ctx, client, ops, 0)
 */
 package otetcd
@@ -0,0 +1,93 @@
+package otetcd
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.etcd.io/etcd/client/v3"
+)
+
+const defaultStatusTimeout = time.Second
+
+// collector is a prometheus.Collector reporting the health of every client made
+// by the Factory. The clients are probed lazily, when the metrics are collected.
+type collector struct {
+	factory     Factory
+	timeout     time.Duration
+	endpoints   *prometheus.Desc
+	reachable   *prometheus.Desc
+	lastSuccess *prometheus.Desc
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newCollector(factory Factory, timeout time.Duration) *collector {
+	return &collector{
+		factory: factory,
+		timeout: timeout,
+		endpoints: prometheus.NewDesc(
+			"etcd_endpoints",
+			"number of endpoints configured",
+			[]string{"name"},
+			nil,
+		),
+		reachable: prometheus.NewDesc(
+			"etcd_reachable",
+			"whether the cluster is reachable, 1 for yes and 0 for no",
+			[]string{"name"},
+			nil,
+		),
+		lastSuccess: prometheus.NewDesc(
+			"etcd_last_status_success_timestamp_seconds",
+			"unix timestamp of the last successful status call",
+			[]string{"name"},
+			nil,
+		),
+		last: make(map[string]time.Time),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.endpoints
+	descs <- c.reachable
+	descs <- c.lastSuccess
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(metrics chan<- prometheus.Metric) {
+	for name, pair := range c.factory.List() {
+		client := pair.Conn.(*clientv3.Client)
+		endpoints := client.Endpoints()
+		metrics <- prometheus.MustNewConstMetric(c.endpoints, prometheus.GaugeValue, float64(len(endpoints)), name)
+
+		reachable := 0.0
+		if c.probe(client, endpoints) {
+			reachable = 1
+		}
+		metrics <- prometheus.MustNewConstMetric(c.reachable, prometheus.GaugeValue, reachable, name)
+
+		c.mu.Lock()
+		if reachable == 1 {
+			c.last[name] = time.Now()
+		}
+		last, ok := c.last[name]
+		c.mu.Unlock()
+		if ok {
+			metrics <- prometheus.MustNewConstMetric(c.lastSuccess, prometheus.GaugeValue, float64(last.UnixNano())/1e9, name)
+		}
+	}
+}
+
+func (c *collector) probe(client *clientv3.Client, endpoints []string) bool {
+	if len(endpoints) == 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	_, err := client.Status(ctx, endpoints[0])
+	return err == nil
+}
@@ -0,0 +1,41 @@
+package otetcd
+
+import (
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints: envDefaultEtcdAddrs,
+			},
+		}},
+		Logger:     log.NewNopLogger(),
+		Registerer: registry,
+	})
+	_, err := out.Factory.Make("default")
+	assert.NoError(t, err)
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = family.GetMetric()[0].GetGauge().GetValue()
+		assert.Equal(t, "default", family.GetMetric()[0].GetLabel()[0].GetValue())
+	}
+	assert.Equal(t, float64(len(envDefaultEtcdAddrs)), values["etcd_endpoints"])
+	assert.Equal(t, 1.0, values["etcd_reachable"])
+	assert.NotZero(t, values["etcd_last_status_success_timestamp_seconds"])
+
+	cleanup()
+	families, err = registry.Gather()
+	assert.NoError(t, err)
+	assert.Empty(t, families)
+}
@@ -0,0 +1,58 @@
+package otetcd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// Mutex is a distributed lock backed by etcd. It holds an etcd session, whose
+// lease keeps the lock alive as long as the process is. The lock is released
+// automatically once the lease expires, for example if the process crashes.
+type Mutex struct {
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+}
+
+// NewMutex creates a Mutex on the key, using the client of the given name. The
+// ttl is the time to live of the session lease, rounded up to seconds. A ttl of
+// zero means the etcd default of 60 seconds.
+//
+// The session ends when the client is closed, releasing the lock. Call
+// Mutex.Close to end it earlier.
+func (r Factory) NewMutex(name, key string, ttl time.Duration) (*Mutex, error) {
+	client, err := r.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	var opts []concurrency.SessionOption
+	if ttl > 0 {
+		opts = append(opts, concurrency.WithTTL(int(math.Ceil(ttl.Seconds()))))
+	}
+	session, err := concurrency.NewSession(client, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create etcd session %s: %w", name, err)
+	}
+	return &Mutex{
+		session: session,
+		mutex:   concurrency.NewMutex(session, key),
+	}, nil
+}
+
+// Lock acquires the lock, blocking until it is acquired or the context is done.
+func (m *Mutex) Lock(ctx context.Context) error {
+	return m.mutex.Lock(ctx)
+}
+
+// Unlock releases the lock.
+func (m *Mutex) Unlock(ctx context.Context) error {
+	return m.mutex.Unlock(ctx)
+}
+
+// Close ends the session, releasing the lock if held.
+func (m *Mutex) Close() error {
+	return m.session.Close()
+}
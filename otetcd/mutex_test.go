@@ -0,0 +1,54 @@
+// +build integration
+
+package otetcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory_NewMutex(t *testing.T) {
+	out, cleanup := provideFactory(factoryIn{
+		Conf: config.MapAdapter{"etcd": map[string]Option{
+			"default": {
+				Endpoints: envDefaultEtcdAddrs,
+			},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	m1, err := out.Factory.NewMutex("default", "/mutex-test", 5*time.Second)
+	assert.NoError(t, err)
+	defer m1.Close()
+	m2, err := out.Factory.NewMutex("default", "/mutex-test", 5*time.Second)
+	assert.NoError(t, err)
+	defer m2.Close()
+
+	assert.NoError(t, m1.Lock(context.Background()))
+
+	acquired := make(chan struct{})
+	go func() {
+		assert.NoError(t, m2.Lock(context.Background()))
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the lock should be held by m1")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	assert.NoError(t, m1.Unlock(context.Background()))
+	select {
+	case <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("m2 should acquire the lock after m1 unlocks")
+	}
+	assert.NoError(t, m2.Unlock(context.Background()))
+}
@@ -3,7 +3,12 @@ package otetcd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
 	"github.com/DoNewsCode/core/config"
+	"github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 )
@@ -44,6 +49,19 @@ type Option struct {
 	// TLS holds the client secure credentials, if any.
 	TLS *tls.Config `json:"-" yaml:"-"`
 
+	// CertFile is the path to the client certificate. It must be used with KeyFile.
+	CertFile string `json:"certFile" yaml:"certFile"`
+
+	// KeyFile is the path to the private key of the client certificate.
+	KeyFile string `json:"keyFile" yaml:"keyFile"`
+
+	// CAFile is the path to the certificate authorities to verify the server
+	// with. If empty, the system pool is used.
+	CAFile string `json:"caFile" yaml:"caFile"`
+
+	// InsecureSkipVerify disables the verification of the server certificate.
+	InsecureSkipVerify bool `json:"insecureSkipVerify" yaml:"insecureSkipVerify"`
+
 	// Username is a user name for authentication.
 	Username string `json:"username" yaml:"username"`
 
@@ -69,4 +87,57 @@ type Option struct {
 
 	// PermitWithoutStream when set will allow client to send keepalive pings to server without any active streams(RPCs).
 	PermitWithoutStream bool `json:"permitWithoutStream" yaml:"permitWithoutStream"`
+
+	// MaxRetries is the number of retries of a call failed with a transient error,
+	// such as Unavailable during a leader change. By default, it is zero, and
+	// the calls are not retried on top of what clientv3 does.
+	MaxRetries uint `json:"maxRetries" yaml:"maxRetries"`
+
+	// RetryBackoff is the wait between two retries. It is only used if MaxRetries
+	// is set.
+	RetryBackoff config.Duration `json:"retryBackoff" yaml:"retryBackoff"`
+}
+
+// retryDialOptions returns the dial options to retry calls, if MaxRetries is set.
+// The interceptors are chained, so they compose with the other interceptors.
+func (o Option) retryDialOptions() []grpc.DialOption {
+	if o.MaxRetries == 0 {
+		return nil
+	}
+	opts := []grpc_retry.CallOption{
+		grpc_retry.WithMax(o.MaxRetries),
+		grpc_retry.WithBackoff(grpc_retry.BackoffLinear(o.RetryBackoff.Duration)),
+	}
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(grpc_retry.UnaryClientInterceptor(opts...)),
+		grpc.WithChainStreamInterceptor(grpc_retry.StreamClientInterceptor(opts...)),
+	}
+}
+
+// tlsConfig builds the *tls.Config from the certificate files. It returns
+// Option.TLS untouched if none of the TLS fields is set.
+func (o Option) tlsConfig() (*tls.Config, error) {
+	if o.CertFile == "" && o.KeyFile == "" && o.CAFile == "" && !o.InsecureSkipVerify {
+		return o.TLS, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: o.InsecureSkipVerify}
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if o.CAFile != "" {
+		pem, err := ioutil.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read certificate authorities: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", o.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
 }
@@ -0,0 +1,72 @@
+package otetcd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOption_tlsConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "otetcd")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile)
+
+	existing := &tls.Config{}
+	conf, err := Option{TLS: existing}.tlsConfig()
+	assert.NoError(t, err)
+	assert.Same(t, existing, conf)
+
+	conf, err = Option{CertFile: certFile, KeyFile: keyFile, CAFile: certFile}.tlsConfig()
+	assert.NoError(t, err)
+	assert.Len(t, conf.Certificates, 1)
+	assert.NotNil(t, conf.RootCAs)
+	assert.False(t, conf.InsecureSkipVerify)
+
+	conf, err = Option{InsecureSkipVerify: true}.tlsConfig()
+	assert.NoError(t, err)
+	assert.True(t, conf.InsecureSkipVerify)
+
+	_, err = Option{CAFile: keyFile}.tlsConfig()
+	assert.Error(t, err)
+
+	_, err = Option{CertFile: filepath.Join(dir, "missing.pem")}.tlsConfig()
+	assert.Error(t, err)
+}
+
+func writeCertificate(t *testing.T, certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+}
+
+func TestOption_retryDialOptions(t *testing.T) {
+	assert.Empty(t, Option{}.retryDialOptions())
+	opts := Option{MaxRetries: 3, RetryBackoff: config.Duration{Duration: 100 * time.Millisecond}}.retryDialOptions()
+	assert.Len(t, opts, 2)
+}
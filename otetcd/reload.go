@@ -0,0 +1,69 @@
+package otetcd
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/di"
+	"github.com/DoNewsCode/core/events"
+	"github.com/hashicorp/go-multierror"
+)
+
+// reloader remembers the Option every client is created with, so that only the
+// clients whose Option changed are closed on reload.
+type reloader struct {
+	conf    contract.ConfigAccessor
+	factory *di.Factory
+
+	mu      sync.Mutex
+	options map[string]Option
+}
+
+func newReloader(conf contract.ConfigAccessor, factory *di.Factory) *reloader {
+	return &reloader{
+		conf:    conf,
+		factory: factory,
+		options: make(map[string]Option),
+	}
+}
+
+// record remembers the Option of the client of the given name.
+func (r *reloader) record(name string, option Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.options[name] = option
+}
+
+// reload unmarshals the configuration again, and closes the clients whose
+// Option changed. They are reconstructed on the next Make.
+func (r *reloader) reload() error {
+	var result error
+	for name := range r.factory.List() {
+		var option Option
+		if err := r.conf.Unmarshal(fmt.Sprintf("etcd.%s", name), &option); err != nil {
+			result = multierror.Append(result, fmt.Errorf("etcd configuration %s not valid: %w", name, err))
+			continue
+		}
+		r.mu.Lock()
+		previous, ok := r.options[name]
+		r.mu.Unlock()
+		if ok && reflect.DeepEqual(previous, option) {
+			continue
+		}
+		r.factory.CloseConn(name)
+	}
+	return result
+}
+
+// subscribe reloads the clients on every events.OnReload.
+func (r *reloader) subscribe(dispatcher contract.Dispatcher) {
+	if dispatcher == nil {
+		return
+	}
+	dispatcher.Subscribe(events.Listen(events.From(events.OnReload{}), func(ctx context.Context, event contract.Event) error {
+		return r.reload()
+	}))
+}
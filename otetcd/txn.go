@@ -0,0 +1,56 @@
+package otetcd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// DefaultMaxTxnOps is the default limit of operations in a single etcd
+// transaction, as set by the --max-txn-ops flag of etcd.
+const DefaultMaxTxnOps = 128
+
+// ChunkedTxn commits the ops in a series of transactions, each containing at most
+// chunkSize ops. A chunkSize of zero means DefaultMaxTxnOps.
+//
+// Atomicity is only guaranteed within a chunk, not across the whole ops. If a
+// chunk fails, the remaining chunks are still committed, and all errors are
+// aggregated in the returned error. A span is created for each chunk.
+func ChunkedTxn(ctx context.Context, client clientv3.KV, ops []clientv3.Op, chunkSize int) error {
+	if chunkSize == 0 {
+		chunkSize = DefaultMaxTxnOps
+	}
+	if chunkSize < 0 {
+		return fmt.Errorf("chunk size must be positive, got %d", chunkSize)
+	}
+	var result error
+	for start := 0; start < len(ops); start += chunkSize {
+		end := start + chunkSize
+		if end > len(ops) {
+			end = len(ops)
+		}
+		if err := commitChunk(ctx, client, ops[start:end], start); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result
+}
+
+func commitChunk(ctx context.Context, client clientv3.KV, ops []clientv3.Op, offset int) error {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "etcd:txn:chunk")
+	defer span.Finish()
+	span.SetTag("offset", offset)
+	span.SetTag("ops", len(ops))
+
+	if _, err := client.Txn(ctx).Then(ops...).Commit(); err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.Error(err))
+		return fmt.Errorf("unable to commit ops %d to %d: %w", offset, offset+len(ops)-1, err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package otetcd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/client/v3"
+)
+
+func TestChunkedTxn(t *testing.T) {
+	client, err := clientv3.New(clientv3.Config{Endpoints: envDefaultEtcdAddrs})
+	assert.NoError(t, err)
+	defer client.Close()
+
+	var ops []clientv3.Op
+	for i := 0; i < 300; i++ {
+		ops = append(ops, clientv3.OpPut(fmt.Sprintf("chunked-txn-test/%d", i), "foo"))
+	}
+	assert.NoError(t, ChunkedTxn(context.Background(), client, ops, 0))
+	defer client.Delete(context.Background(), "chunked-txn-test/", clientv3.WithPrefix())
+
+	resp, err := client.Get(context.Background(), "chunked-txn-test/", clientv3.WithPrefix(), clientv3.WithCountOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(300), resp.Count)
+
+	assert.Error(t, ChunkedTxn(context.Background(), client, ops, -1))
+}
@@ -0,0 +1,71 @@
+package otetcd
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"go.etcd.io/etcd/client/v3"
+)
+
+// WatchEvent is a batch of events received from a watch.
+type WatchEvent struct {
+	// Events are the events of the batch.
+	Events []*clientv3.Event
+	// Revision is the revision of the store when the batch is sent.
+	Revision int64
+	// Err is the error of the watch, if any. The watch is closed by etcd after an
+	// error, for example when the revision is compacted.
+	Err error
+}
+
+// Watch watches the key like clientv3.Watcher.Watch does, and creates a span for
+// every batch of events received, tagged with the key and the revision. The span
+// is a child of the span in the context, if any.
+//
+// The returned channel is closed when the context is cancelled, or when the
+// watch is closed by etcd.
+func Watch(ctx context.Context, client clientv3.Watcher, key string, opts ...clientv3.OpOption) <-chan WatchEvent {
+	ch := make(chan WatchEvent)
+	watchCh := client.Watch(ctx, key, opts...)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp, ok := <-watchCh:
+				if !ok {
+					return
+				}
+				event := traceWatchResponse(ctx, key, resp)
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func traceWatchResponse(ctx context.Context, key string, resp clientv3.WatchResponse) WatchEvent {
+	span, _ := opentracing.StartSpanFromContext(ctx, "etcd:watch")
+	defer span.Finish()
+	span.SetTag("key", key)
+	span.SetTag("revision", resp.Header.Revision)
+	span.SetTag("events", len(resp.Events))
+
+	err := resp.Err()
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogFields(log.Error(err))
+	}
+	return WatchEvent{
+		Events:   resp.Events,
+		Revision: resp.Header.Revision,
+		Err:      err,
+	}
+}
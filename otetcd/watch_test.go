@@ -0,0 +1,50 @@
+package otetcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/client/v3"
+)
+
+type watcher struct {
+	clientv3.Watcher
+	ch chan clientv3.WatchResponse
+}
+
+func (w watcher) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	return w.ch
+}
+
+func TestWatch(t *testing.T) {
+	tracer := mocktracer.New()
+	opentracing.SetGlobalTracer(tracer)
+	defer opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+
+	w := watcher{ch: make(chan clientv3.WatchResponse, 1)}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Watch(ctx, w, "foo")
+
+	w.ch <- clientv3.WatchResponse{
+		Header: etcdserverpb.ResponseHeader{Revision: 42},
+		Events: []*clientv3.Event{{Type: clientv3.EventTypePut}},
+	}
+	event := <-ch
+	assert.NoError(t, event.Err)
+	assert.Equal(t, int64(42), event.Revision)
+	assert.Len(t, event.Events, 1)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "etcd:watch", spans[0].OperationName)
+	assert.Equal(t, "foo", spans[0].Tag("key"))
+	assert.Equal(t, int64(42), spans[0].Tag("revision"))
+
+	cancel()
+	_, ok := <-ch
+	assert.False(t, ok)
+}
@@ -130,7 +130,7 @@ func provideReaderFactory(p in) (ReaderFactory, func()) {
 		conf := fromReaderConfig(readerConfig)
 		conf.Logger = KafkaLogAdapter{Logging: level.Debug(p.Logger)}
 		conf.ErrorLogger = KafkaLogAdapter{Logging: level.Warn(p.Logger)}
-		if p.WriterInterceptor != nil {
+		if p.ReaderInterceptor != nil {
 			p.ReaderInterceptor(name, &conf)
 		}
 		client := kafka.NewReader(conf)
@@ -141,7 +141,7 @@ func provideReaderFactory(p in) (ReaderFactory, func()) {
 			},
 		}, nil
 	})
-	return ReaderFactory{factory}, factory.Close
+	return ReaderFactory{Factory: factory, snapshots: newReaderSnapshots()}, factory.Close
 }
 
 // provideWriterFactory creates WriterFactory. It is a valid injection
@@ -173,7 +173,7 @@ func provideWriterFactory(p in) (WriterFactory, func()) {
 			},
 		}, nil
 	})
-	return WriterFactory{factory}, factory.Close
+	return WriterFactory{Factory: factory, tracer: p.Tracer}, factory.Close
 }
 
 type metricsConf struct {
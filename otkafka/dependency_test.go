@@ -1,11 +1,15 @@
 package otkafka
 
 import (
+	"context"
+	"strconv"
 	"testing"
 
 	"github.com/DoNewsCode/core/config"
 	"github.com/DoNewsCode/core/di"
 	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,6 +42,77 @@ func TestProvideReaderFactory(t *testing.T) {
 	cleanup()
 }
 
+func TestReaderFactory_Stats(t *testing.T) {
+	factory, cleanup := provideReaderFactory(in{
+		Conf: config.MapAdapter{"kafka.reader": map[string]ReaderConfig{
+			"default": {
+				Brokers:  envDefaultKafkaAddrs,
+				Topic:    "Test",
+				MinBytes: 1,
+				MaxBytes: 1024,
+			},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+	_, err := factory.Stats("default")
+	assert.Error(t, err, "the reader must not be created by Stats")
+	assert.Empty(t, factory.List())
+
+	_, err = factory.Make("default")
+	assert.NoError(t, err)
+	stats, err := factory.Stats("default")
+	assert.NoError(t, err)
+	assert.Equal(t, "Test", stats.Topic)
+	assert.Equal(t, int64(1024), stats.MaxBytes)
+
+	// Once collected, the snapshots of the collector are served.
+	factory.snapshots.collect()
+	_, err = factory.Stats("default")
+	assert.Error(t, err)
+	factory.snapshots.store("default", kafka.ReaderStats{Topic: "Test", Messages: 42})
+	stats, err = factory.Stats("default")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), stats.Messages)
+}
+
+func TestProvideReaderFactory_interceptor(t *testing.T) {
+	var intercepted []string
+	factory, cleanup := provideReaderFactory(in{
+		Conf: config.MapAdapter{"kafka.reader": map[string]ReaderConfig{
+			"default": {
+				Brokers: envDefaultKafkaAddrs,
+				Topic:   "Test",
+			},
+		}},
+		Logger: log.NewNopLogger(),
+		ReaderInterceptor: func(name string, reader *kafka.ReaderConfig) {
+			intercepted = append(intercepted, name)
+			reader.GroupID = "intercepted"
+		},
+	})
+	defer cleanup()
+	reader, err := factory.Make("default")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"default"}, intercepted)
+	assert.Equal(t, "intercepted", reader.Config().GroupID)
+
+	// a writer interceptor alone must not affect the readers.
+	factory, cleanup = provideReaderFactory(in{
+		Conf: config.MapAdapter{"kafka.reader": map[string]ReaderConfig{
+			"default": {
+				Brokers: envDefaultKafkaAddrs,
+				Topic:   "Test",
+			},
+		}},
+		Logger:            log.NewNopLogger(),
+		WriterInterceptor: func(name string, writer *kafka.Writer) {},
+	})
+	defer cleanup()
+	_, err = factory.Make("default")
+	assert.NoError(t, err)
+}
+
 func TestProvideWriterFactory(t *testing.T) {
 	factory, cleanup := provideWriterFactory(in{
 		In: di.In{},
@@ -62,6 +137,34 @@ func TestProvideWriterFactory(t *testing.T) {
 	cleanup()
 }
 
+func TestWriterFactory_MakeTraced(t *testing.T) {
+	tracer := mocktracer.New()
+	factory, cleanup := provideWriterFactory(in{
+		Conf: config.MapAdapter{"kafka.writer": map[string]WriterConfig{
+			"default": {
+				Brokers: envDefaultKafkaAddrs,
+				Topic:   "trace",
+			},
+		}},
+		Logger: log.NewNopLogger(),
+		Tracer: tracer,
+	})
+	defer cleanup()
+	writer, err := factory.MakeTraced("default")
+	assert.NoError(t, err)
+
+	msgs := []kafka.Message{{Value: []byte("hello")}}
+	assert.NoError(t, writer.WriteMessages(context.Background(), msgs...))
+
+	headers := make(map[string]string)
+	for _, header := range msgs[0].Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	span := tracer.FinishedSpans()[len(tracer.FinishedSpans())-1]
+	assert.Equal(t, strconv.Itoa(span.SpanContext.TraceID), headers["mockpfx-ids-traceid"])
+	assert.Equal(t, strconv.Itoa(span.SpanContext.SpanID), headers["mockpfx-ids-spanid"])
+}
+
 func TestProvideKafka(t *testing.T) {
 	Out, cleanupReader, cleanupWriter, err := provideKafkaFactory(in{
 		Logger: log.NewNopLogger(),
@@ -38,5 +38,48 @@ writers/readers are provided by github.com/segmentio/kafka-go.
 		writer.WriteMessage(kafka.Message{})
 	})
 
+To inject the span context into the headers of the messages, so that consumers
+can continue the trace with SpanFromMessage, make a traced writer instead:
+
+	c.Invoke(func(factory otkafka.WriterFactory) {
+		writer, err := factory.MakeTraced("foo")
+	})
+
+Transactions
+
+Exactly-once read-process-write requires Kafka transactions: a transactional
+producer (transactional.id with idempotence enabled) that adds the consumed
+offsets to the transaction and commits or aborts it atomically. kafka-go, as of
+the version used here, only sends a transactional id along with the produce
+requests. It doesn't implement the AddPartitionsToTxn, AddOffsetsToTxn,
+TxnOffsetCommit and EndTxn requests, so transactions can't be used with this
+package.
+
+Instead, aim for at-least-once delivery with idempotent handlers. The processor
+in package otkafka/processor commits offsets only after the handler (or the
+batch) succeeds, so a message is redelivered rather than lost on failure.
+
+Oversized Messages
+
+Messages larger than the broker limit fail to deliver, which is easy to miss in
+async mode. The traced Writer can check the size before sending, and hand
+oversized messages to a handler, for example to store the payload in S3 and send
+a pointer instead:
+
+	w := otkafka.Trace(writer, tracer, otkafka.WithOversizedMessageHandler(
+		func(ctx context.Context, msg kafka.Message) (kafka.Message, error) {
+			url, err := uploader.Upload(ctx, "payload", bytes.NewReader(msg.Value))
+			return kafka.Message{Key: msg.Key, Value: []byte(url)}, err
+		},
+	))
+
+Substituted messages carry the x-claim-check header.
+
+Consumer Metrics
+
+The processor in package otkafka/processor records the processed messages, the
+handler errors and the handler latency if a *processor.Metrics is provided, for
+example by observability.ProvideKafkaProcessorMetrics. The metrics are labeled by
+topic only.
 */
 package otkafka
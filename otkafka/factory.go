@@ -1,7 +1,11 @@
 package otkafka
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/DoNewsCode/core/di"
+	"github.com/opentracing/opentracing-go"
 	"github.com/segmentio/kafka-go"
 )
 
@@ -12,6 +16,7 @@ import (
 // kafka config rather than an opaque name such as default.
 type ReaderFactory struct {
 	*di.Factory
+	snapshots *readerSnapshots
 }
 
 // Make returns a *kafka.Reader under the provided configuration entry.
@@ -23,6 +28,68 @@ func (k ReaderFactory) Make(name string) (*kafka.Reader, error) {
 	return client.(*kafka.Reader), nil
 }
 
+// Stats returns the stats of the *kafka.Reader under the provided configuration
+// entry. Only readers already created are reported: asking for another name
+// returns an error, rather than creating a reader that may join a consumer group
+// and trigger a rebalance.
+//
+// kafka.Reader.Stats resets the counters on each call. If the reader metrics are
+// enabled, Stats doesn't call it, and returns the snapshot taken by the last
+// collection of the metrics instead, or an error if none has been taken yet.
+// Otherwise, the counters are those accumulated since the previous call.
+func (k ReaderFactory) Stats(name string) (kafka.ReaderStats, error) {
+	pair, ok := k.List()[name]
+	if !ok {
+		return kafka.ReaderStats{}, fmt.Errorf("kafka reader %s not created", name)
+	}
+	if k.snapshots == nil || !k.snapshots.isCollected() {
+		return pair.Conn.(*kafka.Reader).Stats(), nil
+	}
+	stats, ok := k.snapshots.load(name)
+	if !ok {
+		return kafka.ReaderStats{}, fmt.Errorf("no stats collected for kafka reader %s yet", name)
+	}
+	return stats, nil
+}
+
+// readerSnapshots keeps the stats taken by the reader collector, so that they can
+// be served without resetting the counters of the readers.
+type readerSnapshots struct {
+	mu        sync.Mutex
+	collected bool
+	stats     map[string]kafka.ReaderStats
+}
+
+func newReaderSnapshots() *readerSnapshots {
+	return &readerSnapshots{stats: make(map[string]kafka.ReaderStats)}
+}
+
+// collect marks the stats as taken by a collector.
+func (r *readerSnapshots) collect() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collected = true
+}
+
+func (r *readerSnapshots) isCollected() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.collected
+}
+
+func (r *readerSnapshots) store(name string, stats kafka.ReaderStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats[name] = stats
+}
+
+func (r *readerSnapshots) load(name string) (kafka.ReaderStats, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[name]
+	return stats, ok
+}
+
 // WriterFactory is a *di.Factory that creates *kafka.Writer.
 //
 // Unlike other database providers, the kafka factories don't bundle a default
@@ -30,6 +97,7 @@ func (k ReaderFactory) Make(name string) (*kafka.Reader, error) {
 // kafka config rather than an opaque name such as default.
 type WriterFactory struct {
 	*di.Factory
+	tracer opentracing.Tracer
 }
 
 // Make returns a *kafka.Writer under the provided configuration entry.
@@ -40,3 +108,18 @@ func (k WriterFactory) Make(name string) (*kafka.Writer, error) {
 	}
 	return client.(*kafka.Writer), nil
 }
+
+// MakeTraced is like Make, but returns the writer decorated by Trace, so that
+// the span context is injected into the headers of every message written. The
+// tracer of the factory is used, or a noop tracer if none was provided.
+func (k WriterFactory) MakeTraced(name string, opts ...WriterOption) (*Writer, error) {
+	writer, err := k.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	tracer := k.tracer
+	if tracer == nil {
+		tracer = opentracing.NoopTracer{}
+	}
+	return Trace(writer, tracer, opts...), nil
+}
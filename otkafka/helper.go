@@ -7,16 +7,20 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
-// SpanFromMessage reads the message
+// SpanFromMessage starts a consumer span for the message. If the message carries
+// trace headers, for example written by InjectSpan or the traced Writer, the
+// span continues the trace of the producer. If the headers are missing or
+// malformed, a root span is started instead, so consuming never fails because
+// of tracing. The returned error is always nil, and kept for compatibility.
 func SpanFromMessage(ctx context.Context, tracer opentracing.Tracer, message *kafka.Message) (opentracing.Span, context.Context, error) {
 	carrier := getCarrier(message)
 	spanContext, err := tracer.Extract(opentracing.TextMap, carrier)
-	if err != nil && err != opentracing.ErrSpanContextNotFound {
-		return nil, nil, err
+	if err != nil {
+		spanContext = nil
 	}
 	span := tracer.StartSpan("kafka reader", ext.RPCServerOption(spanContext))
 	if err != nil && err != opentracing.ErrSpanContextNotFound {
-		return nil, nil, err
+		span.LogKV("event", "malformed trace headers", "error", err.Error())
 	}
 	ext.SpanKind.Set(span, ext.SpanKindConsumerEnum)
 	ext.PeerService.Set(span, "kafka")
@@ -27,6 +31,39 @@ func SpanFromMessage(ctx context.Context, tracer opentracing.Tracer, message *ka
 	return span, opentracing.ContextWithSpan(ctx, span), nil
 }
 
+// InjectSpan writes the span context into the headers of the message, in the
+// format read by SpanFromMessage, so that the consumer continues the trace. The
+// span in the context is used if any. Otherwise, a producer span is started as
+// the root of the trace. Existing trace headers of the message are replaced.
+func InjectSpan(ctx context.Context, tracer opentracing.Tracer, message *kafka.Message) error {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		span = tracer.StartSpan("kafka writer", ext.SpanKindProducer)
+		defer span.Finish()
+	}
+	carrier := make(opentracing.TextMapCarrier)
+	if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		return err
+	}
+	setCarrier(message, carrier)
+	return nil
+}
+
+// setCarrier writes the carrier into the headers of the message, replacing the
+// headers of the same keys.
+func setCarrier(msg *kafka.Message, carrier opentracing.TextMapCarrier) {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+len(carrier))
+	for _, header := range msg.Headers {
+		if _, ok := carrier[header.Key]; !ok {
+			headers = append(headers, header)
+		}
+	}
+	for k, v := range carrier {
+		headers = append(headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	msg.Headers = headers
+}
+
 func getCarrier(msg *kafka.Message) opentracing.TextMapCarrier {
 
 	var mapCarrier = make(opentracing.TextMapCarrier)
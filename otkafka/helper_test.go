@@ -2,10 +2,12 @@ package otkafka
 
 import (
 	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
 	"github.com/opentracing/opentracing-go/mocktracer"
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestHelper_no_parent(t *testing.T) {
@@ -13,3 +15,59 @@ func TestHelper_no_parent(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, span.(*mocktracer.MockSpan).ParentID)
 }
+
+func TestInjectSpan(t *testing.T) {
+	tracer := mocktracer.New()
+	parent, ctx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "parent")
+	msg := kafka.Message{Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}, {Key: "mockpfx-ids-spanid", Value: []byte("stale")}}}
+	assert.NoError(t, InjectSpan(ctx, tracer, &msg))
+	parent.Finish()
+	assert.Len(t, msg.Headers, 4)
+	assert.Equal(t, "foo", msg.Headers[0].Key)
+
+	span, _, err := SpanFromMessage(context.Background(), tracer, &msg)
+	assert.NoError(t, err)
+	span.Finish()
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, span.(*mocktracer.MockSpan).ParentID)
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.TraceID, span.(*mocktracer.MockSpan).SpanContext.TraceID)
+
+	// without a span in the context, a root producer span is started.
+	msg = kafka.Message{}
+	assert.NoError(t, InjectSpan(context.Background(), tracer, &msg))
+	producer := tracer.FinishedSpans()[len(tracer.FinishedSpans())-1]
+	assert.Equal(t, "kafka writer", producer.OperationName)
+	span, _, err = SpanFromMessage(context.Background(), tracer, &msg)
+	assert.NoError(t, err)
+	assert.Equal(t, producer.SpanContext.SpanID, span.(*mocktracer.MockSpan).ParentID)
+}
+
+func TestHelper_parent(t *testing.T) {
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("parent")
+	carrier := make(opentracing.TextMapCarrier)
+	assert.NoError(t, tracer.Inject(parent.Context(), opentracing.TextMap, carrier))
+	msg := kafka.Message{}
+	for k, v := range carrier {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+
+	span, ctx, err := SpanFromMessage(context.Background(), tracer, &msg)
+	assert.NoError(t, err)
+	assert.Equal(t, span, opentracing.SpanFromContext(ctx))
+	assert.Equal(t, parent.(*mocktracer.MockSpan).SpanContext.SpanID, span.(*mocktracer.MockSpan).ParentID)
+}
+
+func TestHelper_malformed(t *testing.T) {
+	tracer := mocktracer.New()
+	msg := kafka.Message{Headers: []kafka.Header{
+		{Key: "mockpfx-ids-traceid", Value: []byte("1")},
+		{Key: "mockpfx-ids-spanid", Value: []byte("1")},
+		{Key: "mockpfx-ids-sampled", Value: []byte("not a bool")},
+	}}
+	span, ctx, err := SpanFromMessage(context.Background(), tracer, &msg)
+	assert.NoError(t, err)
+	assert.NotNil(t, ctx)
+	assert.Zero(t, span.(*mocktracer.MockSpan).ParentID)
+	span.Finish()
+	assert.NotEmpty(t, tracer.FinishedSpans()[0].Logs())
+}
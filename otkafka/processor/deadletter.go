@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+// DeadLetterReasonHeader is the header added to the messages routed to the
+// dead letter topic. Its value is the error returned by the last attempt.
+const DeadLetterReasonHeader = "x-dead-letter-reason"
+
+// messageWriter is the part of *kafka.Writer used for dead letters.
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// WithDeadLetter is an option that routes the messages failing Handler.Handle
+// to a dead letter topic instead of stopping the processor. Each message is
+// handled at most maxAttempts times, then written to the writer with its
+// original key, value and headers, plus the DeadLetterReasonHeader. The writer
+// must be configured with the dead letter topic. Once written, the message is
+// committed as if it were handled.
+func WithDeadLetter(writer *kafka.Writer, maxAttempts int) Option {
+	return func(processor *Processor) {
+		if writer == nil {
+			return
+		}
+		if maxAttempts <= 0 {
+			maxAttempts = 1
+		}
+		processor.deadLetter = writer
+		processor.maxAttempts = maxAttempts
+	}
+}
+
+// retry calls Handler.Handle until it succeeds or maxAttempts is reached. It
+// returns the number of attempts made.
+func (h *handler) retry(ctx context.Context, msg *kafka.Message) (interface{}, int, error) {
+	var (
+		v   interface{}
+		err error
+		i   int
+	)
+	for i = 1; ; i++ {
+		v, err = h.observe(ctx, msg)
+		if err == nil || i >= h.maxAttempts || ctx.Err() != nil {
+			return v, i, err
+		}
+	}
+}
+
+// sendDeadLetter writes the message to the dead letter writer.
+func (h *handler) sendDeadLetter(ctx context.Context, msg *kafka.Message, reason error) error {
+	headers := make([]kafka.Header, 0, len(msg.Headers)+1)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers, kafka.Header{Key: DeadLetterReasonHeader, Value: []byte(reason.Error())})
+	dead := kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+	if err := h.deadLetter.WriteMessages(ctx, dead); err != nil {
+		return errors.Wrapf(err, "writing message %d of topic %s to dead letter", msg.Offset, msg.Topic)
+	}
+	return nil
+}
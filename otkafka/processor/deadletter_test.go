@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func TestWithDeadLetter(t *testing.T) {
+	var p Processor
+	WithDeadLetter(&kafka.Writer{}, 0)(&p)
+	assert.NotNil(t, p.deadLetter)
+	assert.Equal(t, 1, p.maxAttempts)
+}
+
+func TestHandler_deadLetter(t *testing.T) {
+	var (
+		writer   = &fakeWriter{}
+		tracer   = mocktracer.New()
+		attempts int
+	)
+	h := &handler{
+		tracer: tracer,
+		handleFunc: func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			attempts++
+			return nil, errors.New("boom")
+		},
+		deadLetter:  writer,
+		maxAttempts: 3,
+	}
+	msg := &kafka.Message{
+		Topic:   "fake",
+		Key:     []byte("key"),
+		Value:   []byte("value"),
+		Headers: []kafka.Header{{Key: "foo", Value: []byte("bar")}},
+	}
+	v, err := h.invoke(context.Background(), msg)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	assert.Equal(t, 3, attempts)
+
+	assert.Len(t, writer.messages, 1)
+	dead := writer.messages[0]
+	assert.Equal(t, "", dead.Topic)
+	assert.Equal(t, []byte("key"), dead.Key)
+	assert.Equal(t, []byte("value"), dead.Value)
+	assert.Equal(t, []kafka.Header{
+		{Key: "foo", Value: []byte("bar")},
+		{Key: DeadLetterReasonHeader, Value: []byte("boom")},
+	}, dead.Headers)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, true, spans[0].Tag("error"))
+	assert.Equal(t, 3, spans[0].Tag("attempts"))
+}
+
+func TestHandler_noDeadLetter(t *testing.T) {
+	h := &handler{
+		handleFunc: func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	_, err := h.invoke(context.Background(), &kafka.Message{})
+	assert.EqualError(t, err, "boom")
+}
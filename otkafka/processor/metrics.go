@@ -0,0 +1,52 @@
+package processor
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+)
+
+// Metrics is a bundle of RED metrics for the Processor. When provided, every
+// handler records the number of processed messages, the number of handler
+// errors and the handler latency in seconds. Every field is optional.
+//
+// The only label set is "topic". Partitions are deliberately left out to keep
+// the cardinality bounded, as the number of partitions may grow over time.
+type Metrics struct {
+	// Processed counts the messages handled successfully.
+	Processed metrics.Counter
+	// Errors counts the messages failed in Handler.Handle.
+	Errors metrics.Counter
+	// Latency observes the duration of Handler.Handle in seconds.
+	Latency metrics.Histogram
+}
+
+// with returns a copy of the bundle with the topic label set.
+func (m *Metrics) with(topic string) *Metrics {
+	labeled := &Metrics{}
+	if m.Processed != nil {
+		labeled.Processed = m.Processed.With("topic", topic)
+	}
+	if m.Errors != nil {
+		labeled.Errors = m.Errors.With("topic", topic)
+	}
+	if m.Latency != nil {
+		labeled.Latency = m.Latency.With("topic", topic)
+	}
+	return labeled
+}
+
+func (m *Metrics) observe(begin time.Time, err error) {
+	if m.Latency != nil {
+		m.Latency.Observe(time.Since(begin).Seconds())
+	}
+	if err != nil {
+		if m.Errors != nil {
+			m.Errors.Add(1)
+		}
+		return
+	}
+	if m.Processed != nil {
+		m.Processed.Add(1)
+	}
+}
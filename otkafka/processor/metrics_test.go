@@ -0,0 +1,97 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/kit/metrics/generic"
+	"github.com/go-kit/kit/metrics/prometheus"
+	"github.com/oklog/run"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_invoke(t *testing.T) {
+	bundle := &Metrics{
+		Processed: generic.NewCounter("processed"),
+		Errors:    generic.NewCounter("errors"),
+		Latency:   generic.NewHistogram("latency", 10),
+	}
+
+	h := &handler{
+		handleFunc: func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			if string(msg.Value) == "bad" {
+				return nil, errors.New("bad message")
+			}
+			return nil, nil
+		},
+		metrics: bundle.with("foo"),
+	}
+
+	_, err := h.invoke(context.Background(), &kafka.Message{Value: []byte("good")})
+	assert.NoError(t, err)
+	_, err = h.invoke(context.Background(), &kafka.Message{Value: []byte("bad")})
+	assert.Error(t, err)
+
+	labeled := h.metrics
+	assert.Equal(t, 1.0, labeled.Processed.(*generic.Counter).Value())
+	assert.Equal(t, 1.0, labeled.Errors.(*generic.Counter).Value())
+	assert.Equal(t, []string{"topic", "foo"}, labeled.Processed.(*generic.Counter).LabelValues())
+
+	h.metrics = nil
+	_, err = h.invoke(context.Background(), &kafka.Message{Value: []byte("good")})
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, labeled.Processed.(*generic.Counter).Value())
+}
+
+func TestProcessor_metricsScrape(t *testing.T) {
+	const n = 10
+	registry := stdprometheus.NewRegistry()
+	processed := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{Name: "processed"}, []string{"topic"})
+	latency := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{Name: "latency"}, []string{"topic"})
+	registry.MustRegister(processed, latency)
+	bundle := &Metrics{
+		Processed: prometheus.NewCounter(processed),
+		Latency:   prometheus.NewHistogram(latency),
+	}
+
+	reader := newFakeReader(n)
+	p := &Processor{handlers: []*handler{{
+		reader:  reader,
+		msgCh:   make(chan *kafka.Message, n),
+		info:    &Info{},
+		metrics: bundle.with("fake"),
+		handleFunc: func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			return nil, nil
+		},
+	}}}
+
+	var group run.Group
+	p.ProvideRunGroup(&group)
+	group.Add(func() error {
+		select {
+		case <-reader.done:
+		case <-time.After(5 * time.Second):
+			t.Error("timeout waiting for commits")
+		}
+		return nil
+	}, func(err error) {})
+	assert.NoError(t, group.Run())
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 2)
+	for _, family := range families {
+		metric := family.GetMetric()[0]
+		assert.Equal(t, "fake", metric.GetLabel()[0].GetValue())
+		switch family.GetName() {
+		case "processed":
+			assert.Equal(t, float64(n), metric.GetCounter().GetValue())
+		case "latency":
+			assert.Equal(t, uint64(n), metric.GetHistogram().GetSampleCount())
+		}
+	}
+}
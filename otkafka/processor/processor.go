@@ -8,6 +8,8 @@ import (
 	"github.com/DoNewsCode/core/otkafka"
 	"github.com/go-kit/kit/log"
 	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 	"github.com/pkg/errors"
 	"github.com/segmentio/kafka-go"
 )
@@ -17,6 +19,11 @@ type Processor struct {
 	maker    otkafka.ReaderMaker
 	handlers []*handler
 	logger   log.Logger
+	metrics  *Metrics
+	tracer   opentracing.Tracer
+
+	deadLetter  messageWriter
+	maxAttempts int
 }
 
 // Handler only include Info and Handle func.
@@ -46,15 +53,25 @@ type in struct {
 	Handlers []Handler `group:"ProcessorHandler"`
 	Maker    otkafka.ReaderMaker
 	Logger   log.Logger
+	Metrics  *Metrics           `optional:"true"`
+	Tracer   opentracing.Tracer `optional:"true"`
 }
 
+// Option configures the Processor.
+type Option func(*Processor)
+
 // New create *Processor Module.
-func New(i in) (*Processor, error) {
+func New(i in, opts ...Option) (*Processor, error) {
 	e := &Processor{
 		maker:    i.Maker,
 		logger:   i.Logger,
+		metrics:  i.Metrics,
+		tracer:   i.Tracer,
 		handlers: []*handler{},
 	}
+	for _, f := range opts {
+		f(e)
+	}
 	if len(i.Handlers) == 0 {
 		return nil, errors.New("empty handler list")
 	}
@@ -66,6 +83,18 @@ func New(i in) (*Processor, error) {
 	return e, nil
 }
 
+// NewWithOptions returns a constructor of *Processor Module with the options
+// applied, which can be passed to core.AddModuleFunc.
+// 	Usage:
+// 		c.AddModuleFunc(processor.NewWithOptions(
+// 			processor.WithDeadLetter(writer, 3),
+// 		))
+func NewWithOptions(opts ...Option) func(i in) (*Processor, error) {
+	return func(i in) (*Processor, error) {
+		return New(i, opts...)
+	}
+}
+
 // Out to provide Handler to in.Handlers.
 type Out struct {
 	di.Out
@@ -108,6 +137,13 @@ func (e *Processor) addHandler(h Handler) error {
 		reader:     reader,
 		handleFunc: h.Handle,
 		info:       h.Info(),
+		tracer:     e.tracer,
+
+		deadLetter:  e.deadLetter,
+		maxAttempts: e.maxAttempts,
+	}
+	if e.metrics != nil {
+		hd.metrics = e.metrics.with(reader.Config().Topic)
 	}
 
 	batchHandler, isBatchHandler := h.(BatchHandler)
@@ -190,16 +226,27 @@ func (e *Processor) ProvideCloser() {
 	}
 }
 
+// messageReader is the part of *kafka.Reader used by the handler.
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
 // handler private processor
 // todo It's a bit messy
 type handler struct {
-	reader     *kafka.Reader
+	reader     messageReader
 	batchCh    chan *batchInfo
 	msgCh      chan *kafka.Message
 	handleFunc HandleFunc
 	batchFunc  BatchFunc
 	info       *Info
 	ticker     *time.Ticker
+	metrics    *Metrics
+	tracer     opentracing.Tracer
+
+	deadLetter  messageWriter
+	maxAttempts int
 }
 
 // read fetch message from kafka
@@ -226,7 +273,7 @@ func (h *handler) handle(ctx context.Context) error {
 	for {
 		select {
 		case msg := <-h.msgCh:
-			v, err := h.handleFunc(ctx, msg)
+			v, err := h.invoke(ctx, msg)
 			if err != nil {
 				return err
 			}
@@ -244,6 +291,42 @@ func (h *handler) handle(ctx context.Context) error {
 	}
 }
 
+// invoke calls Handler.Handle within a span continuing the trace of the
+// producer, and records the metrics if any. With a dead letter writer, the
+// message is retried and then routed to the dead letter topic, in which case
+// it is considered handled.
+func (h *handler) invoke(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+	var span opentracing.Span
+	if h.tracer != nil {
+		span, ctx, _ = otkafka.SpanFromMessage(ctx, h.tracer, msg)
+		defer span.Finish()
+	}
+	v, attempts, err := h.retry(ctx, msg)
+	if err == nil {
+		return v, nil
+	}
+	if span != nil {
+		ext.Error.Set(span, true)
+		span.SetTag("attempts", attempts)
+		span.LogKV("error", err.Error())
+	}
+	if h.deadLetter == nil {
+		return nil, err
+	}
+	return nil, h.sendDeadLetter(ctx, msg, err)
+}
+
+// observe calls Handler.Handle and records the metrics if any.
+func (h *handler) observe(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+	if h.metrics == nil {
+		return h.handleFunc(ctx, msg)
+	}
+	begin := time.Now()
+	v, err := h.handleFunc(ctx, msg)
+	h.metrics.observe(begin, err)
+	return v, err
+}
+
 // batch Call BatchHandler.Batch and commit *kafka.Message.
 func (h *handler) batch(ctx context.Context) error {
 	var data = make([]interface{}, 0)
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oklog/run"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeReader delivers the messages, then blocks until the context is done. The
+// done channel is closed once every message is committed.
+type fakeReader struct {
+	messages  chan kafka.Message
+	mu        sync.Mutex
+	committed []kafka.Message
+	done      chan struct{}
+}
+
+func newFakeReader(n int) *fakeReader {
+	r := &fakeReader{messages: make(chan kafka.Message, n), done: make(chan struct{})}
+	for i := 0; i < n; i++ {
+		r.messages <- kafka.Message{Topic: "fake", Offset: int64(i), Value: []byte(strconv.Itoa(i))}
+	}
+	return r
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	select {
+	case msg := <-r.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return kafka.Message{}, ctx.Err()
+	}
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.committed = append(r.committed, msgs...)
+	if len(r.committed) == cap(r.messages) {
+		close(r.done)
+	}
+	return nil
+}
+
+func TestProcessor_fakeReader(t *testing.T) {
+	const n = 50
+	var (
+		reader = newFakeReader(n)
+		tracer = mocktracer.New()
+		mu     sync.Mutex
+		seen   = make(map[string]bool)
+	)
+	p := &Processor{handlers: []*handler{{
+		reader: reader,
+		msgCh:  make(chan *kafka.Message, 10),
+		info:   &Info{HandleWorker: 4},
+		tracer: tracer,
+		handleFunc: func(ctx context.Context, msg *kafka.Message) (interface{}, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[string(msg.Value)] = true
+			return nil, nil
+		},
+	}}}
+
+	var group run.Group
+	p.ProvideRunGroup(&group)
+	stop := make(chan struct{})
+	group.Add(func() error {
+		select {
+		case <-reader.done:
+		case <-time.After(5 * time.Second):
+			t.Error("timeout waiting for commits")
+		case <-stop:
+		}
+		return nil
+	}, func(err error) {
+		close(stop)
+	})
+	assert.NoError(t, group.Run())
+
+	assert.Len(t, seen, n)
+	assert.Len(t, reader.committed, n)
+	assert.Len(t, tracer.FinishedSpans(), n)
+	assert.Equal(t, "fake", tracer.FinishedSpans()[0].Tag("topic"))
+}
@@ -46,7 +46,12 @@ type ReaderStats struct {
 }
 
 // newCollector creates a new kafka reader wrapper containing the name of the reader.
+// The collector becomes the only caller of kafka.Reader.Stats, which resets the
+// counters, and shares its snapshots with ReaderFactory.Stats.
 func newReaderCollector(factory ReaderFactory, stats *ReaderStats, interval time.Duration) *readerCollector {
+	if factory.snapshots != nil {
+		factory.snapshots.collect()
+	}
 	return &readerCollector{
 		factory:  factory,
 		stats:    stats,
@@ -59,6 +64,9 @@ func (d *readerCollector) collectConnectionStats() {
 	for k, v := range d.factory.List() {
 		reader := v.Conn.(*kafka.Reader)
 		stats := reader.Stats()
+		if d.factory.snapshots != nil {
+			d.factory.snapshots.store(k, stats)
+		}
 		withValues := []string{"reader", k, "client_id", stats.ClientID, "topic", stats.Topic, "partition", stats.Partition}
 
 		d.stats.Dials.With(withValues...).Add(float64(stats.Dials))
@@ -2,7 +2,9 @@ package otkafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/opentracing/opentracing-go"
@@ -10,11 +12,30 @@ import (
 	"github.com/segmentio/kafka-go"
 )
 
+// DefaultMaxMessageBytes is the default size limit of a single message, matching
+// the default message.max.bytes of kafka brokers.
+const DefaultMaxMessageBytes = 1024 * 1024
+
+// HeaderClaimCheck is the header added to messages substituted by an
+// OversizedMessageHandler.
+const HeaderClaimCheck = "x-claim-check"
+
+// ErrMessageTooLarge is returned by Writer.WriteMessages when a message exceeds
+// the size limit and no OversizedMessageHandler is able to shrink it.
+var ErrMessageTooLarge = errors.New("kafka message too large")
+
+// OversizedMessageHandler handles a message exceeding the size limit before it
+// is sent. It should return a substitute message, usually a pointer to where the
+// original payload has been stored (the "claim check" pattern).
+type OversizedMessageHandler func(ctx context.Context, msg kafka.Message) (kafka.Message, error)
+
 // Writer is a decorator around kafka.Writer that provides tracing capabilities.
 type Writer struct {
 	*kafka.Writer
-	tracer opentracing.Tracer
-	logger log.Logger
+	tracer           opentracing.Tracer
+	logger           log.Logger
+	maxMessageBytes  int
+	oversizedHandler OversizedMessageHandler
 }
 
 // WriterOption is type that configures the Writer.
@@ -27,6 +48,30 @@ func WithLogger(logger log.Logger) WriterOption {
 	}
 }
 
+// WithMaxMessageBytes is an option that guards against messages larger than the
+// given number of bytes. The size of a message is estimated as the sum of its
+// key, value and headers, including the tracing headers. Messages over the
+// limit are passed to the OversizedMessageHandler, or rejected with
+// ErrMessageTooLarge if there is none.
+func WithMaxMessageBytes(num int) WriterOption {
+	return func(writer *Writer) {
+		writer.maxMessageBytes = num
+	}
+}
+
+// WithOversizedMessageHandler is an option that routes messages over the size
+// limit to the handler, and sends the returned substitute instead. The
+// substitute is tagged with the HeaderClaimCheck header. Unless set by
+// WithMaxMessageBytes, the limit defaults to DefaultMaxMessageBytes.
+func WithOversizedMessageHandler(handler OversizedMessageHandler) WriterOption {
+	return func(writer *Writer) {
+		writer.oversizedHandler = handler
+		if writer.maxMessageBytes == 0 {
+			writer.maxMessageBytes = DefaultMaxMessageBytes
+		}
+	}
+}
+
 // Trace takes a kafka.Writer and returns a decorated Writer.
 func Trace(writer *kafka.Writer, tracer opentracing.Tracer, opts ...WriterOption) *Writer {
 	w := &Writer{
@@ -51,18 +96,25 @@ func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error
 
 	carrier := make(opentracing.TextMapCarrier)
 	err := w.tracer.Inject(span.Context(), opentracing.TextMap, carrier)
-	if err != nil && w.logger != nil {
-		_ = level.Warn(w.logger).Log("err", fmt.Sprintf("unable to inject tracing context: %s", err.Error()))
-	} else {
-		_ = level.Debug(w.logger).Log("msg", fmt.Sprintf("trace injected"))
+	if w.logger != nil {
+		if err != nil {
+			_ = level.Warn(w.logger).Log("err", fmt.Sprintf("unable to inject tracing context: %s", err.Error()))
+		} else {
+			_ = level.Debug(w.logger).Log("msg", fmt.Sprintf("trace injected"))
+		}
 	}
 
 	for i := range msgs {
-		for k := range carrier {
-			var header kafka.Header
-			header.Key = k
-			header.Value = []byte(carrier[k])
-			msgs[i].Headers = append(msgs[i].Headers, header)
+		setCarrier(&msgs[i], carrier)
+	}
+
+	// The size is checked with the tracing headers, which count towards the
+	// limit of the broker as well.
+	if w.maxMessageBytes > 0 {
+		if err := w.guardSize(ctx, msgs, carrier); err != nil {
+			ext.Error.Set(span, true)
+			span.LogKV("error", err.Error())
+			return err
 		}
 	}
 
@@ -73,3 +125,72 @@ func (w *Writer) WriteMessages(ctx context.Context, msgs ...kafka.Message) error
 	}
 	return err
 }
+
+// WriteMessagesTraced writes a batch of messages with w, within a single
+// producer span tagged with the number of messages and their total bytes. The
+// span context is injected into the headers of every message, so that the
+// consumers continue the trace. Unlike Writer, it works with a plain
+// kafka.Writer and doesn't create a span per message.
+func WriteMessagesTraced(ctx context.Context, w *kafka.Writer, tracer opentracing.Tracer, msgs ...kafka.Message) error {
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "kafka batch writer")
+	defer span.Finish()
+
+	var size int
+	for i := range msgs {
+		size += messageSize(msgs[i])
+	}
+	ext.SpanKind.Set(span, ext.SpanKindProducerEnum)
+	ext.PeerService.Set(span, "kafka")
+	span.SetTag("topic", w.Topic)
+	span.SetTag("count", len(msgs))
+	span.SetTag("bytes", size)
+
+	carrier := make(opentracing.TextMapCarrier)
+	if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err != nil {
+		span.LogKV("event", "unable to inject tracing context", "error", err.Error())
+	}
+	for i := range msgs {
+		setCarrier(&msgs[i], carrier)
+	}
+
+	if err := w.WriteMessages(ctx, msgs...); err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+		return err
+	}
+	return nil
+}
+
+// guardSize replaces oversized messages in place with the substitutes returned
+// by the OversizedMessageHandler. The substitutes receive the tracing headers of
+// the carrier before their own size is checked.
+func (w *Writer) guardSize(ctx context.Context, msgs []kafka.Message, carrier opentracing.TextMapCarrier) error {
+	for i := range msgs {
+		size := messageSize(msgs[i])
+		if size <= w.maxMessageBytes {
+			continue
+		}
+		if w.oversizedHandler == nil {
+			return fmt.Errorf("message of %d bytes exceeds the limit of %d bytes: %w", size, w.maxMessageBytes, ErrMessageTooLarge)
+		}
+		substitute, err := w.oversizedHandler(ctx, msgs[i])
+		if err != nil {
+			return fmt.Errorf("unable to handle oversized message: %w", err)
+		}
+		substitute.Headers = append(substitute.Headers, kafka.Header{Key: HeaderClaimCheck, Value: []byte("true")})
+		setCarrier(&substitute, carrier)
+		if size := messageSize(substitute); size > w.maxMessageBytes {
+			return fmt.Errorf("substitute message of %d bytes exceeds the limit of %d bytes: %w", size, w.maxMessageBytes, ErrMessageTooLarge)
+		}
+		msgs[i] = substitute
+	}
+	return nil
+}
+
+func messageSize(msg kafka.Message) int {
+	size := len(msg.Key) + len(msg.Value)
+	for _, header := range msg.Headers {
+		size += len(header.Key) + len(header.Value)
+	}
+	return size
+}
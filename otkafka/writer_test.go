@@ -2,6 +2,8 @@ package otkafka
 
 import (
 	"context"
+	"errors"
+	"net"
 	"strings"
 	"testing"
 
@@ -48,3 +50,82 @@ func Test_fromWriterConfig(t *testing.T) {
 	writer := fromWriterConfig(WriterConfig{})
 	assert.Equal(t, strings.Join(envDefaultKafkaAddrs, ","), writer.Addr.String())
 }
+
+func TestWriter_MaxMessageBytes(t *testing.T) {
+	t.Parallel()
+	kw := kafka.Writer{
+		Addr:  kafka.TCP(envDefaultKafkaAddrs...),
+		Topic: "trace",
+	}
+	tracer := mocktracer.New()
+
+	w := Trace(&kw, tracer, WithMaxMessageBytes(3))
+	err := w.WriteMessages(context.Background(), kafka.Message{Value: []byte(`hello`)})
+	assert.True(t, errors.Is(err, ErrMessageTooLarge))
+
+	var handled bool
+	w = Trace(&kw, tracer, WithOversizedMessageHandler(func(ctx context.Context, msg kafka.Message) (kafka.Message, error) {
+		handled = true
+		return kafka.Message{Value: []byte(`s3://bucket/hello`)}, nil
+	}), WithMaxMessageBytes(256))
+	msgs := []kafka.Message{{Value: []byte(strings.Repeat("a", 257))}}
+	err = w.WriteMessages(context.Background(), msgs...)
+	assert.NoError(t, err)
+	assert.True(t, handled)
+}
+
+func TestWriter_guardSize(t *testing.T) {
+	t.Parallel()
+	w := Trace(&kafka.Writer{}, opentracing.NoopTracer{}, WithOversizedMessageHandler(func(ctx context.Context, msg kafka.Message) (kafka.Message, error) {
+		return kafka.Message{Value: []byte(`pointer`)}, nil
+	}))
+	assert.Equal(t, DefaultMaxMessageBytes, w.maxMessageBytes)
+
+	msgs := []kafka.Message{{Value: []byte(`small`)}, {Value: make([]byte, DefaultMaxMessageBytes+1)}}
+	carrier := opentracing.TextMapCarrier{"trace": "id"}
+	err := w.guardSize(context.Background(), msgs, carrier)
+	assert.NoError(t, err)
+	assert.Equal(t, "small", string(msgs[0].Value))
+	assert.Equal(t, "pointer", string(msgs[1].Value))
+	assert.Equal(t, HeaderClaimCheck, msgs[1].Headers[0].Key)
+	assert.Equal(t, "trace", msgs[1].Headers[1].Key)
+}
+
+func TestWriter_WriteMessages_sizeWithTraceHeaders(t *testing.T) {
+	t.Parallel()
+	tracer := mocktracer.New()
+	w := Trace(&kafka.Writer{}, tracer, WithMaxMessageBytes(10))
+
+	// The value alone fits, but not with the tracing headers.
+	err := w.WriteMessages(context.Background(), kafka.Message{Value: make([]byte, 10)})
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(ctx context.Context, addr net.Addr, request kafka.Request) (kafka.Response, error) {
+	return nil, errors.New("broker unavailable")
+}
+
+func TestWriteMessagesTraced(t *testing.T) {
+	tracer := mocktracer.New()
+	kw := &kafka.Writer{
+		Addr:        kafka.TCP("127.0.0.1:1"),
+		Topic:       "trace",
+		MaxAttempts: 1,
+		Transport:   failingTransport{},
+	}
+	msgs := []kafka.Message{{Value: []byte("foo")}, {Key: []byte("k"), Value: []byte("bar")}}
+	err := WriteMessagesTraced(context.Background(), kw, tracer, msgs...)
+	assert.Error(t, err)
+
+	spans := tracer.FinishedSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "kafka batch writer", spans[0].OperationName)
+	assert.Equal(t, 2, spans[0].Tag("count"))
+	assert.Equal(t, 7, spans[0].Tag("bytes"))
+	assert.Equal(t, true, spans[0].Tag("error"))
+	for _, msg := range msgs {
+		assert.NotEmpty(t, msg.Headers)
+	}
+}
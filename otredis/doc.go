@@ -27,6 +27,8 @@ package otredis exports the configuration in the following format:
 	        poolTimeout: 0s
 	        idleTimeout: 0s
 	        idleCheckFrequency: 0s
+	        commandTimeout: 0s
+	        validate: false
 	        maxRedirects: 0
 	        readOnly: false
 	        routeByLatency: false
@@ -54,5 +56,70 @@ otredis.Maker to factory a redis.UniversalClient with a specific configuration e
 		// do something with client
 	})
 
+Health Check
+
+Factory.CheckHealth confirms a client is connected, for example in a readiness
+probe. Every node of a cluster is pinged.
+
+	err := factory.CheckHealth(ctx, "default")
+
+Semaphore
+
+To limit the concurrency of a resource shared by many instances, use a
+distributed semaphore.
+
+	sem := otredis.NewSemaphore(redisClient, "downstream", 10)
+	release, err := sem.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+Holders that crash without releasing are evicted after the stale timeout
+(30s by default). No fairness is guaranteed among waiters.
+
+Lock
+
+For mutual exclusion, create a lock from the Factory. The lock expires after
+the ttl, so long-running holders should call Refresh.
+
+	lock, err := factory.NewLock("default", "report", 10*time.Second)
+	if err != nil {
+		return err
+	}
+	if err := lock.Lock(ctx); err != nil {
+		return err
+	}
+	defer lock.Unlock(ctx)
+
+Pub/Sub
+
+Factory.Publish embeds the trace context in the payload, and Factory.Subscribe
+delivers the messages with a span continuing the trace of the publisher:
+
+	pubsub, err := factory.Subscribe(ctx, "default", "events")
+	if err != nil {
+		return err
+	}
+	for msg := range pubsub.Channel() {
+		handle(msg.Context, msg.Payload)
+		msg.Finish()
+	}
+
+The subscription ends when the context is cancelled. As the trace context is
+embedded in the payload, the subscribers of the channel must all use
+Factory.Subscribe.
+
+Local Cache
+
+Hot, read-mostly keys can be served from an in-process cache by providing a
+ClientDecorator:
+
+	c.Provide(di.Deps{func() otredis.ClientDecorator {
+		return otredis.WithLocalCache(1000, time.Second)
+	}})
+
+Only the writes made through the same client invalidate the cache, so values
+may be stale for up to the ttl.
 */
 package otredis
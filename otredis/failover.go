@@ -0,0 +1,193 @@
+package otredis
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-redis/redis/v8"
+)
+
+// FailoverEvent is dispatched when a failover or a redirection is detected on a
+// redis client created by the Factory.
+type FailoverEvent struct {
+	// Name is the name of the redis configuration entry.
+	Name string
+	// Old is the address of the previous master, if known.
+	Old string
+	// New is the address of the new master, if known.
+	New string
+}
+
+// FailoverOption configures the failover detection.
+type FailoverOption func(detector *failoverDetector)
+
+// WithFailoverHandler is an option that calls the handler whenever a failover or
+// a redirection is detected. Either address may be empty if it is not known.
+func WithFailoverHandler(handler func(old, new string)) FailoverOption {
+	return func(detector *failoverDetector) {
+		detector.handlers = append(detector.handlers, func(ctx context.Context, old, new string) {
+			handler(old, new)
+		})
+	}
+}
+
+// WithFailoverCounter is an option that increments the counter whenever a
+// failover or a redirection is detected.
+func WithFailoverCounter(counter metrics.Counter) FailoverOption {
+	return func(detector *failoverDetector) {
+		detector.handlers = append(detector.handlers, func(ctx context.Context, old, new string) {
+			counter.Add(1)
+		})
+	}
+}
+
+// WithFailoverDispatcher is an option that dispatches a FailoverEvent whenever a
+// failover or a redirection is detected.
+func WithFailoverDispatcher(dispatcher contract.Dispatcher, name string) FailoverOption {
+	return func(detector *failoverDetector) {
+		detector.handlers = append(detector.handlers, func(ctx context.Context, old, new string) {
+			_ = dispatcher.Dispatch(ctx, events.Of(FailoverEvent{Name: name, Old: old, New: new}))
+		})
+	}
+}
+
+/*
+DetectFailover makes failovers of the client visible to the application. It
+returns a function to stop the detection.
+
+Failovers are detected from two sources. First, command errors are inspected:
+
+	MOVED <slot> <addr>   the slot has moved to another cluster node.
+	ASK <slot> <addr>     the slot is being migrated to another cluster node.
+	READONLY ...          the node the client talks to is no longer a master.
+
+Note the cluster client follows MOVED and ASK redirections transparently, so
+those are only seen here when the redirections are exhausted, or when a non
+cluster client talks to a cluster. Second, if opts.MasterName is set, the
+"+switch-master" channel of the sentinels is subscribed, and every master switch
+of that name is reported.
+*/
+func DetectFailover(client redis.UniversalClient, opts *redis.UniversalOptions, options ...FailoverOption) (stop func()) {
+	detector := &failoverDetector{
+		addrs: strings.Join(opts.Addrs, ", "),
+	}
+	for _, f := range options {
+		f(detector)
+	}
+	client.AddHook(detector)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	if opts.MasterName == "" {
+		close(done)
+		return cancel
+	}
+	go func() {
+		defer close(done)
+		detector.watchSentinels(ctx, opts)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+type failoverDetector struct {
+	addrs    string
+	handlers []func(ctx context.Context, old, new string)
+}
+
+func (f *failoverDetector) notify(ctx context.Context, old, new string) {
+	for _, handler := range f.handlers {
+		handler(ctx, old, new)
+	}
+}
+
+func (f *failoverDetector) inspect(ctx context.Context, err error) {
+	if err == nil || err == redis.Nil {
+		return
+	}
+	msg := err.Error()
+	switch {
+	case strings.HasPrefix(msg, "MOVED "), strings.HasPrefix(msg, "ASK "):
+		parts := strings.Fields(msg)
+		if len(parts) == 3 {
+			f.notify(ctx, f.addrs, parts[2])
+		}
+	case strings.HasPrefix(msg, "READONLY "):
+		f.notify(ctx, f.addrs, "")
+	}
+}
+
+func (f *failoverDetector) watchSentinels(ctx context.Context, opts *redis.UniversalOptions) {
+	for _, addr := range opts.Addrs {
+		sentinel := redis.NewSentinelClient(&redis.Options{
+			Addr:      addr,
+			Username:  opts.Username,
+			Password:  opts.SentinelPassword,
+			TLSConfig: opts.TLSConfig,
+		})
+		pubsub := sentinel.Subscribe(ctx, "+switch-master")
+		if _, err := pubsub.Receive(ctx); err != nil {
+			_ = pubsub.Close()
+			_ = sentinel.Close()
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		f.receive(ctx, pubsub.Channel(), opts.MasterName)
+		_ = pubsub.Close()
+		_ = sentinel.Close()
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (f *failoverDetector) receive(ctx context.Context, ch <-chan *redis.Message, masterName string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			// <master name> <old ip> <old port> <new ip> <new port>
+			parts := strings.Fields(msg.Payload)
+			if len(parts) != 5 || parts[0] != masterName {
+				continue
+			}
+			f.notify(ctx, net.JoinHostPort(parts[1], parts[2]), net.JoinHostPort(parts[3], parts[4]))
+		}
+	}
+}
+
+// BeforeProcess implements redis.Hook.
+func (f *failoverDetector) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess implements redis.Hook.
+func (f *failoverDetector) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	f.inspect(ctx, cmd.Err())
+	return nil
+}
+
+// BeforeProcessPipeline implements redis.Hook.
+func (f *failoverDetector) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline implements redis.Hook.
+func (f *failoverDetector) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		f.inspect(ctx, cmd.Err())
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package otredis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DoNewsCode/core/contract"
+	"github.com/DoNewsCode/core/events"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFailoverDetector_inspect(t *testing.T) {
+	t.Parallel()
+
+	var got [][2]string
+	detector := &failoverDetector{addrs: "127.0.0.1:6379"}
+	WithFailoverHandler(func(old, new string) {
+		got = append(got, [2]string{old, new})
+	})(detector)
+
+	detector.inspect(context.Background(), nil)
+	detector.inspect(context.Background(), redis.Nil)
+	detector.inspect(context.Background(), errors.New("ERR unknown command"))
+	detector.inspect(context.Background(), errors.New("MOVED 3999 127.0.0.1:6381"))
+	detector.inspect(context.Background(), errors.New("ASK 3999 127.0.0.1:6382"))
+	detector.inspect(context.Background(), errors.New("READONLY You can't write against a read only replica."))
+
+	assert.Equal(t, [][2]string{
+		{"127.0.0.1:6379", "127.0.0.1:6381"},
+		{"127.0.0.1:6379", "127.0.0.1:6382"},
+		{"127.0.0.1:6379", ""},
+	}, got)
+}
+
+func TestFailoverDetector_receive(t *testing.T) {
+	t.Parallel()
+
+	dispatcher := &events.SyncDispatcher{}
+	var got FailoverEvent
+	dispatcher.Subscribe(events.Listen(events.From(FailoverEvent{}), func(ctx context.Context, event contract.Event) error {
+		got = event.Data().(FailoverEvent)
+		return nil
+	}))
+	detector := &failoverDetector{}
+	WithFailoverDispatcher(dispatcher, "default")(detector)
+
+	ch := make(chan *redis.Message, 2)
+	ch <- &redis.Message{Payload: "other 127.0.0.1 6379 127.0.0.1 6380"}
+	ch <- &redis.Message{Payload: "mymaster 127.0.0.1 6379 127.0.0.1 6380"}
+	close(ch)
+	detector.receive(context.Background(), ch, "mymaster")
+
+	assert.Equal(t, FailoverEvent{Name: "default", Old: "127.0.0.1:6379", New: "127.0.0.1:6380"}, got)
+}
@@ -0,0 +1,235 @@
+package otredis
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ClientDecorator wraps the redis.UniversalClient made by the Factory for the
+// given configuration entry. Provide one to the container to decorate every
+// client, for example with WithLocalCache.
+//
+// A decorator returning a wrapper should give access to the wrapped client
+// with an Unwrap() redis.UniversalClient method, so that Unwrap can reach the
+// concrete client, for example to ping every node of a cluster.
+type ClientDecorator func(name string, client redis.UniversalClient) redis.UniversalClient
+
+// Unwrap returns the client wrapped by the decorators, or the client itself if
+// it is not decorated.
+func Unwrap(client redis.UniversalClient) redis.UniversalClient {
+	for {
+		wrapper, ok := client.(interface{ Unwrap() redis.UniversalClient })
+		if !ok {
+			return client
+		}
+		client = wrapper.Unwrap()
+	}
+}
+
+// readOnlyCommands are the commands that don't invalidate the local cache.
+var readOnlyCommands = map[string]struct{}{
+	"get":    {},
+	"mget":   {},
+	"exists": {},
+	"ttl":    {},
+	"pttl":   {},
+	"strlen": {},
+	"type":   {},
+	"ping":   {},
+}
+
+// flushCommands are the commands that invalidate every key, even though they
+// take no key as argument.
+var flushCommands = map[string]struct{}{
+	"flushdb":  {},
+	"flushall": {},
+	"swapdb":   {},
+}
+
+// WithLocalCache returns a ClientDecorator that caches the results of GET in an
+// in-process LRU of at most size keys, each kept for at most ttl. Other
+// commands go to redis as usual.
+//
+// The cache is invalidated by the writes made through the same client, that is,
+// any command other than a few read-only ones evicts the keys in its arguments,
+// and FLUSHDB, FLUSHALL and SWAPDB clear the cache.
+// Writes made by other clients, including other instances of the application,
+// are not observed: a cached value can be stale for up to ttl. Only use it for
+// hot, read-mostly keys that tolerate such staleness.
+func WithLocalCache(size int, ttl time.Duration) ClientDecorator {
+	return func(name string, client redis.UniversalClient) redis.UniversalClient {
+		cache := newLocalCache(size, ttl)
+		client.AddHook(invalidationHook{cache: cache})
+		return cachedClient{UniversalClient: client, cache: cache}
+	}
+}
+
+// cachedClient serves GET from the local cache when possible.
+type cachedClient struct {
+	redis.UniversalClient
+	cache *localCache
+}
+
+// Unwrap returns the decorated client.
+func (c cachedClient) Unwrap() redis.UniversalClient {
+	return c.UniversalClient
+}
+
+// Get returns the value of the key, from the local cache if present.
+func (c cachedClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	if value, ok := c.cache.get(key); ok {
+		return redis.NewStringResult(value, nil)
+	}
+	gen := c.cache.generation()
+	cmd := c.UniversalClient.Get(ctx, key)
+	if cmd.Err() == nil {
+		c.cache.add(key, cmd.Val(), gen)
+	}
+	return cmd
+}
+
+// invalidationHook evicts the keys written through the client from the cache.
+type invalidationHook struct {
+	cache *localCache
+}
+
+func (h invalidationHook) invalidate(cmd redis.Cmder) {
+	if _, ok := readOnlyCommands[cmd.Name()]; ok {
+		return
+	}
+	if _, ok := flushCommands[cmd.Name()]; ok {
+		h.cache.clear()
+		return
+	}
+	args := cmd.Args()
+	for i := 1; i < len(args); i++ {
+		if key, ok := args[i].(string); ok {
+			h.cache.remove(key)
+		}
+	}
+	h.cache.bump()
+}
+
+// BeforeProcess implements redis.Hook.
+func (h invalidationHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess implements redis.Hook.
+func (h invalidationHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.invalidate(cmd)
+	return nil
+}
+
+// BeforeProcessPipeline implements redis.Hook.
+func (h invalidationHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline implements redis.Hook.
+func (h invalidationHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.invalidate(cmd)
+	}
+	return nil
+}
+
+// localCache is a LRU with expiration. Its generation is bumped on every
+// invalidation, so that a value read from redis before a concurrent write is
+// not cached after the write.
+type localCache struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	gen   uint64
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key    string
+	value  string
+	expire time.Time
+}
+
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *localCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expire) {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+		return "", false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.value, true
+}
+
+// add caches the value, unless the cache has been invalidated since gen.
+func (c *localCache) add(key, value string, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen || c.size <= 0 {
+		return
+	}
+	entry := &cacheEntry{key: key, value: value, expire: time.Now().Add(c.ttl)}
+	if elem, ok := c.items[key]; ok {
+		elem.Value = entry
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *localCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.ll.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// clear removes every key, and bumps the generation.
+func (c *localCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.gen++
+}
+
+func (c *localCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+func (c *localCache) bump() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+}
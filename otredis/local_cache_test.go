@@ -0,0 +1,126 @@
+package otredis
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingHook counts the commands sent to redis.
+type countingHook struct {
+	count *int32
+}
+
+func (h countingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	atomic.AddInt32(h.count, 1)
+	return ctx, nil
+}
+
+func (h countingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h countingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	atomic.AddInt32(h.count, int32(len(cmds)))
+	return ctx, nil
+}
+
+func (h countingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestWithLocalCache(t *testing.T) {
+	var count int32
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+		Decorator: func(name string, client redis.UniversalClient) redis.UniversalClient {
+			client.AddHook(countingHook{count: &count})
+			return WithLocalCache(10, time.Minute)(name, client)
+		},
+	})
+	defer cleanup()
+	client, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	ctx := context.Background()
+	defer client.Del(ctx, "test:local-cache")
+
+	assert.NoError(t, client.Set(ctx, "test:local-cache", "foo", 0).Err())
+	atomic.StoreInt32(&count, 0)
+	for i := 0; i < 3; i++ {
+		value, err := client.Get(ctx, "test:local-cache").Result()
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", value)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// writes through the same client invalidate the cache.
+	assert.NoError(t, client.Set(ctx, "test:local-cache", "bar", 0).Err())
+	value, err := client.Get(ctx, "test:local-cache").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", value)
+
+	pipe := client.Pipeline()
+	pipe.Del(ctx, "test:local-cache")
+	_, err = pipe.Exec(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, redis.Nil, client.Get(ctx, "test:local-cache").Err())
+}
+
+func TestLocalCache(t *testing.T) {
+	cache := newLocalCache(2, time.Minute)
+	cache.add("a", "1", cache.generation())
+	cache.add("b", "2", cache.generation())
+	cache.get("a")
+	cache.add("c", "3", cache.generation())
+
+	_, ok := cache.get("b")
+	assert.False(t, ok, "the least recently used key is evicted")
+	value, ok := cache.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+
+	// a value read before an invalidation is not cached.
+	gen := cache.generation()
+	cache.bump()
+	cache.add("d", "4", gen)
+	_, ok = cache.get("d")
+	assert.False(t, ok)
+
+	cache = newLocalCache(2, time.Millisecond)
+	cache.add("a", "1", cache.generation())
+	time.Sleep(2 * time.Millisecond)
+	_, ok = cache.get("a")
+	assert.False(t, ok, "expired")
+}
+
+func TestInvalidationHook_flush(t *testing.T) {
+	ctx := context.Background()
+	for _, name := range []string{"flushdb", "flushall", "swapdb"} {
+		cache := newLocalCache(10, time.Minute)
+		cache.add("a", "1", cache.generation())
+		gen := cache.generation()
+		hook := invalidationHook{cache: cache}
+		assert.NoError(t, hook.AfterProcess(ctx, redis.NewStatusCmd(ctx, name)))
+		_, ok := cache.get("a")
+		assert.False(t, ok, name)
+		cache.add("b", "2", gen)
+		_, ok = cache.get("b")
+		assert.False(t, ok, "a value read before %s is not cached", name)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"127.0.0.1:1"}})
+	defer client.Close()
+	decorated := WithLocalCache(10, time.Minute)("default", client)
+	decorated = WithLocalCache(10, time.Minute)("default", decorated)
+	assert.Same(t, client, Unwrap(decorated))
+	assert.Same(t, client, Unwrap(client))
+}
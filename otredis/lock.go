@@ -0,0 +1,123 @@
+package otredis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/xid"
+)
+
+// ErrLockNotHeld is returned by Unlock and Refresh when the lock is not held,
+// either because it was never acquired, or because it expired and has been
+// taken by someone else.
+var ErrLockNotHeld = errors.New("redis lock not held")
+
+// releaseScript deletes the lock only if it still holds the given token, so that
+// a holder whose lock has expired doesn't release the lock of the next holder.
+var releaseScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+    return redis.call("del", KEYS[1])
+end
+return 0
+`)
+
+// extendScript resets the ttl of the lock only if it still holds the given
+// token.
+var extendScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+    return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Lock is a mutual exclusion lock on a single redis instance, acquired with SET
+// NX PX and a random token. It is not the multi-instance Redlock algorithm: the
+// lock is only as available as the redis instance behind it.
+//
+// The lock expires after the ttl unless refreshed, so that a crashed holder
+// doesn't block the others forever. Holders running longer than the ttl must
+// call Refresh periodically. A *Lock is safe for concurrent use, but it is
+// meant to be held by a single goroutine at a time.
+type Lock struct {
+	client       redis.UniversalClient
+	key          string
+	ttl          time.Duration
+	pollInterval time.Duration
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewLock creates a *Lock on the key, using the redis client under the provided
+// configuration entry.
+func (r Factory) NewLock(name, key string, ttl time.Duration) (*Lock, error) {
+	client, err := r.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{
+		client:       client,
+		key:          key,
+		ttl:          ttl,
+		pollInterval: 100 * time.Millisecond,
+	}, nil
+}
+
+// Lock blocks until the lock is acquired or the context is done.
+func (l *Lock) Lock(ctx context.Context) error {
+	token := xid.New().String()
+	for {
+		ok, err := l.client.SetNX(ctx, l.key, token, l.ttl).Result()
+		if err != nil {
+			return fmt.Errorf("unable to acquire lock %s: %w", l.key, err)
+		}
+		if ok {
+			l.mu.Lock()
+			l.token = token
+			l.mu.Unlock()
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("unable to acquire lock %s: %w", l.key, ctx.Err())
+		case <-time.After(l.pollInterval):
+		}
+	}
+}
+
+// Unlock releases the lock. It returns ErrLockNotHeld if the lock is no longer
+// held by l.
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	token := l.token
+	l.token = ""
+	l.mu.Unlock()
+	return l.run(ctx, releaseScript, token)
+}
+
+// Refresh resets the ttl of the lock. It returns ErrLockNotHeld if the lock is
+// no longer held by l.
+func (l *Lock) Refresh(ctx context.Context) error {
+	l.mu.Lock()
+	token := l.token
+	l.mu.Unlock()
+	return l.run(ctx, extendScript, token, l.ttl.Milliseconds())
+}
+
+func (l *Lock) run(ctx context.Context, script *redis.Script, token string, args ...interface{}) error {
+	if token == "" {
+		return ErrLockNotHeld
+	}
+	res, err := script.Run(ctx, l.client, []string{l.key}, append([]interface{}{token}, args...)...).Int()
+	if err != nil {
+		return fmt.Errorf("unable to run script on lock %s: %w", l.key, err)
+	}
+	if res == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package otredis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	var (
+		wg      sync.WaitGroup
+		holders int32
+		maxSeen int32
+	)
+	for i := 0; i < 2; i++ {
+		lock, err := redisOut.Factory.NewLock("default", "test:lock", time.Second)
+		assert.NoError(t, err)
+		lock.pollInterval = time.Millisecond
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				assert.NoError(t, lock.Lock(context.Background()))
+				n := atomic.AddInt32(&holders, 1)
+				if n > atomic.LoadInt32(&maxSeen) {
+					atomic.StoreInt32(&maxSeen, n)
+				}
+				time.Sleep(2 * time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				assert.NoError(t, lock.Unlock(context.Background()))
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), maxSeen)
+}
+
+func TestLock_expired(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+	ctx := context.Background()
+
+	first, _ := redisOut.Factory.NewLock("default", "test:lock:expired", time.Second)
+	second, _ := redisOut.Factory.NewLock("default", "test:lock:expired", time.Second)
+	assert.Equal(t, ErrLockNotHeld, first.Unlock(ctx))
+
+	assert.NoError(t, first.Lock(ctx))
+	assert.NoError(t, first.Refresh(ctx))
+
+	// simulates the expiration of the first lock.
+	client, _ := redisOut.Factory.Make("default")
+	client.Del(ctx, "test:lock:expired")
+	assert.NoError(t, second.Lock(ctx))
+
+	assert.Equal(t, ErrLockNotHeld, first.Refresh(ctx))
+	assert.Equal(t, ErrLockNotHeld, first.Unlock(ctx))
+	assert.NoError(t, second.Unlock(ctx))
+}
@@ -0,0 +1,84 @@
+package otredis
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector is a prometheus.Collector reporting the connection pool stats of
+// every client made by the Factory. The stats are read when the metrics are
+// collected.
+type poolCollector struct {
+	factory    Factory
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newPoolCollector(factory Factory) *poolCollector {
+	return &poolCollector{
+		factory: factory,
+		hits: prometheus.NewDesc(
+			"redis_pool_hits_total",
+			"number of times free connection was found in the pool",
+			[]string{"name"},
+			nil,
+		),
+		misses: prometheus.NewDesc(
+			"redis_pool_misses_total",
+			"number of times free connection was NOT found in the pool",
+			[]string{"name"},
+			nil,
+		),
+		timeouts: prometheus.NewDesc(
+			"redis_pool_timeouts_total",
+			"number of times a wait timeout occurred",
+			[]string{"name"},
+			nil,
+		),
+		totalConns: prometheus.NewDesc(
+			"redis_pool_total_connections",
+			"number of total connections in the pool",
+			[]string{"name"},
+			nil,
+		),
+		idleConns: prometheus.NewDesc(
+			"redis_pool_idle_connections",
+			"number of idle connections in the pool",
+			[]string{"name"},
+			nil,
+		),
+		staleConns: prometheus.NewDesc(
+			"redis_pool_stale_connections_total",
+			"number of stale connections removed from the pool",
+			[]string{"name"},
+			nil,
+		),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *poolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.hits
+	descs <- c.misses
+	descs <- c.timeouts
+	descs <- c.totalConns
+	descs <- c.idleConns
+	descs <- c.staleConns
+}
+
+// Collect implements prometheus.Collector.
+func (c *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	for name, pair := range c.factory.List() {
+		stats := pair.Conn.(redis.UniversalClient).PoolStats()
+		metrics <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits), name)
+		metrics <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses), name)
+		metrics <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts), name)
+		metrics <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns), name)
+		metrics <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns), name)
+		metrics <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns), name)
+	}
+}
@@ -0,0 +1,63 @@
+package otredis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics/discard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:       config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger:     log.NewNopLogger(),
+		Registerer: registry,
+	})
+	client, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, client.Ping(context.Background()).Err())
+	}
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	values := make(map[string]float64)
+	for _, family := range families {
+		metric := family.GetMetric()[0]
+		assert.Equal(t, "default", metric.GetLabel()[0].GetValue())
+		if metric.GetCounter() != nil {
+			values[family.GetName()] = metric.GetCounter().GetValue()
+		} else {
+			values[family.GetName()] = metric.GetGauge().GetValue()
+		}
+	}
+	assert.Len(t, values, 6)
+	assert.NotZero(t, values["redis_pool_hits_total"])
+	assert.NotZero(t, values["redis_pool_total_connections"])
+
+	cleanup()
+	families, err = registry.Gather()
+	assert.NoError(t, err)
+	assert.Empty(t, families)
+}
+
+func TestPoolCollector_gauges(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := discard.NewGauge()
+	_, cleanup := provideRedisFactory(in{
+		Conf:       config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger:     log.NewNopLogger(),
+		Registerer: registry,
+		Gauges:     &Gauges{Hits: gauge, Misses: gauge, Timeouts: gauge, TotalConns: gauge, IdleConns: gauge, StaleConns: gauge},
+	})
+	defer cleanup()
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Empty(t, families, "the pool stats are already reported by the gauges")
+}
@@ -1,7 +1,10 @@
 package otredis
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/DoNewsCode/core/config"
@@ -12,6 +15,7 @@ import (
 	"github.com/go-kit/kit/log/level"
 	"github.com/go-redis/redis/v8"
 	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 /*
@@ -23,11 +27,28 @@ Maker, the default redis.UniversalClient and exported configs.
 		contract.ConfigAccessor
 		RedisConfigurationInterceptor `optional:"true"`
 		opentracing.Tracer            `optional:"true"`
+		prometheus.Registerer         `optional:"true"`
+		ClientDecorator               `optional:"true"`
 	Provide:
 		Maker
 		Factory
 		redis.UniversalClient
 		*collector
+
+If contract.Dispatcher is available, every client made by the Factory detects
+failovers and dispatches FailoverEvent. See DetectFailover for details.
+
+The connection pool stats of every client are reported once. If *Gauges is
+available, for example from observability.ProvideRedisMetrics, they are set on
+its gauges, redis_hit_connections, redis_total_connections and so on, labeled
+by dbname. Otherwise, if a prometheus.Registerer is available, they are
+reported as redis_pool_hits_total, redis_pool_total_connections and so on,
+labeled by name.
+
+The clients, including the default redis.UniversalClient, are owned by the
+Factory, and closed by it when the container shuts down. Users don't need to
+close them. Doing so anyway is harmless: the Factory closes each client once,
+and ignores the error of a client already closed.
 */
 func Providers() []interface{} {
 	return []interface{}{provideRedisFactory, provideDefaultClient, provideConfig}
@@ -49,6 +70,9 @@ type Maker interface {
 // specific configuration entry.
 type Factory struct {
 	*di.Factory
+	conf    contract.ConfigAccessor
+	tracer  opentracing.Tracer
+	scripts *scriptRegistry
 }
 
 // Make creates redis.UniversalClient using a specific configuration entry.
@@ -60,6 +84,71 @@ func (r Factory) Make(name string) (redis.UniversalClient, error) {
 	return client.(redis.UniversalClient), nil
 }
 
+// Names returns the sorted names of the connections configured under "redis".
+// The "default" connection can be made without configuration, so it is only
+// listed if configured.
+func (r Factory) Names() []string {
+	return internal.ConfiguredNames(r.conf, "redis")
+}
+
+// Connections returns the sorted names of the connections currently created by
+// the factory.
+func (r Factory) Connections() []string {
+	return internal.CreatedNames(r.Factory)
+}
+
+// CloseConn closes the client of the given name and removes it from the
+// factory, leaving the other clients intact. The next Make creates a new client,
+// for example after its configuration changed or its backend recovered. It
+// returns the error of closing the client, and nil if no client of that name
+// was created or it is already closed.
+func (r Factory) CloseConn(name string) error {
+	pair, ok := r.Factory.Evict(name)
+	if !ok {
+		return nil
+	}
+	err := pair.Conn.(redis.UniversalClient).Close()
+	if pair.Closer != nil {
+		pair.Closer()
+	}
+	if err != nil && !errors.Is(err, redis.ErrClosed) {
+		return fmt.Errorf("unable to close redis %s: %w", name, err)
+	}
+	return nil
+}
+
+// PoolStats returns the connection pool stats of the client under the provided
+// configuration entry. For cluster clients, the stats are summed across nodes.
+func (r Factory) PoolStats(name string) (*redis.PoolStats, error) {
+	client, err := r.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	return client.PoolStats(), nil
+}
+
+// CheckHealth makes sure the client of the given name is connected, by sending a
+// PING. For cluster clients, every node is pinged and the first failure is
+// reported. It respects the deadline of the context, so it can be used in
+// readiness probes.
+func (r Factory) CheckHealth(ctx context.Context, name string) error {
+	client, err := r.Make(name)
+	if err != nil {
+		return fmt.Errorf("redis %s unhealthy: %w", name, err)
+	}
+	if cluster, ok := Unwrap(client).(*redis.ClusterClient); ok {
+		err = cluster.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			return shard.Ping(ctx).Err()
+		})
+	} else {
+		err = client.Ping(ctx).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("redis %s unhealthy: %w", name, err)
+	}
+	return nil
+}
+
 // in is the injection parameter for provideRedisFactory.
 type in struct {
 	di.In
@@ -70,6 +159,8 @@ type in struct {
 	Tracer      opentracing.Tracer            `optional:"true"`
 	Gauges      *Gauges                       `optional:"true"`
 	Dispatcher  contract.Dispatcher           `optional:"true"`
+	Registerer  prometheus.Registerer         `optional:"true"`
+	Decorator   ClientDecorator               `optional:"true"`
 }
 
 // out is the result of provideRedisFactory.
@@ -84,6 +175,7 @@ type out struct {
 // provideRedisFactory creates Factory and redis.UniversalClient. It is a valid
 // dependency for package core.
 func provideRedisFactory(p in) (out, func()) {
+	var validated sync.Map
 	factory := di.NewFactory(func(name string) (di.Pair, error) {
 		var (
 			base RedisUniversalOptions
@@ -98,8 +190,10 @@ func provideRedisFactory(p in) (out, func()) {
 			}
 		}
 
+		// Copy the addresses so that an interceptor modifying them in place
+		// doesn't leak into the configuration, or into the next Make.
 		full = redis.UniversalOptions{
-			Addrs:              base.Addrs,
+			Addrs:              append([]string(nil), base.Addrs...),
 			DB:                 base.DB,
 			Username:           base.Username,
 			Password:           base.Password,
@@ -138,14 +232,41 @@ func provideRedisFactory(p in) (out, func()) {
 				},
 			)
 		}
+		if base.CommandTimeout.Duration > 0 {
+			client.AddHook(timeoutHook{timeout: base.CommandTimeout.Duration})
+		}
+		stopDetection := func() {}
+		if p.Dispatcher != nil {
+			stopDetection = DetectFailover(client, &full, WithFailoverDispatcher(p.Dispatcher, name))
+		}
+		var conn redis.UniversalClient = client
+		if p.Decorator != nil {
+			conn = p.Decorator(name, client)
+		}
+		if base.Validate {
+			validated.Store(conn, struct{}{})
+		}
+		// The closer may race with a concurrent Close of the factory, so it
+		// only runs once. A client already closed by the user is fine, as the
+		// error of closing it again is ignored.
+		var once sync.Once
 		return di.Pair{
-			Conn: client,
+			Conn: conn,
 			Closer: func() {
-				_ = client.Close()
+				once.Do(func() {
+					validated.Delete(conn)
+					stopDetection()
+					_ = client.Close()
+				})
 			},
 		}, nil
-	})
-	redisFactory := Factory{factory}
+	}, di.WithValidator(func(conn interface{}) bool {
+		if _, ok := validated.Load(conn); !ok {
+			return true
+		}
+		return !closed(conn)
+	}))
+	redisFactory := Factory{Factory: factory, conf: p.Conf, tracer: p.Tracer, scripts: newScriptRegistry()}
 	redisFactory.SubscribeReloadEventFrom(p.Dispatcher)
 	var collector *collector
 	if p.Gauges != nil {
@@ -159,9 +280,36 @@ func provideRedisFactory(p in) (out, func()) {
 		Collector: collector,
 	}
 
-	return redisOut, redisFactory.Close
+	// The Gauges already report the pool stats, don't export them twice.
+	if p.Registerer == nil || p.Gauges != nil {
+		return redisOut, redisFactory.Close
+	}
+	poolCollector := newPoolCollector(redisFactory)
+	if err := p.Registerer.Register(poolCollector); err != nil {
+		_ = p.Logger.Log("msg", "unable to register redis metrics", "err", err)
+		return redisOut, redisFactory.Close
+	}
+	return redisOut, func() {
+		p.Registerer.Unregister(poolCollector)
+		redisFactory.Close()
+	}
+}
+
+// pingTimeout bounds the validation of a cached client.
+const pingTimeout = time.Second
+
+// closed reports whether the redis client has been closed, by sending a PING.
+// It runs on every Make of the clients configured with validate, so that a
+// client closed by the user is rebuilt instead of handed out. Other failures
+// don't reject the client, which reconnects on its own once redis is back.
+func closed(conn interface{}) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	return errors.Is(conn.(redis.UniversalClient).Ping(ctx).Err(), redis.ErrClosed)
 }
 
+// provideDefaultClient provides the "default" client of the factory, which
+// remains owned by the factory.
 func provideDefaultClient(maker Maker) (redis.UniversalClient, error) {
 	return maker.Make("default")
 }
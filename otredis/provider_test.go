@@ -1,7 +1,10 @@
 package otredis
 
 import (
+	"context"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/DoNewsCode/core/config"
 	"github.com/go-kit/kit/log"
@@ -32,6 +35,215 @@ func TestNewRedisFactory(t *testing.T) {
 	cleanup()
 }
 
+func TestFactory_Names(t *testing.T) {
+	conf, err := config.NewConfig(config.WithProviderLayer(rawbytes.Provider([]byte(`
+redis:
+  default:
+    db: 0
+  alternative:
+    db: 1
+  unused:
+    db: 2
+`)), yaml.Parser()))
+	assert.NoError(t, err)
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   conf,
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	assert.Equal(t, []string{"alternative", "default", "unused"}, redisOut.Factory.Names())
+	assert.Empty(t, redisOut.Factory.Connections())
+	_, err = redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	_, err = redisOut.Factory.Make("alternative")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alternative", "default"}, redisOut.Factory.Connections())
+
+	redisOut, cleanup = provideRedisFactory(in{
+		Conf:   config.MapAdapter{},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+	assert.Empty(t, redisOut.Factory.Names())
+}
+
+func TestFactory_CloseConn(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf: config.MapAdapter{"redis": map[string]RedisUniversalOptions{
+			"default":     {},
+			"alternative": {},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	def, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	alt, err := redisOut.Factory.Make("alternative")
+	assert.NoError(t, err)
+
+	assert.NoError(t, redisOut.Factory.CloseConn("default"))
+	assert.NoError(t, redisOut.Factory.CloseConn("default"))
+	assert.Equal(t, redis.ErrClosed, def.Ping(context.Background()).Err())
+	assert.NoError(t, alt.Ping(context.Background()).Err())
+	assert.Equal(t, []string{"alternative"}, redisOut.Factory.Connections())
+
+	remade, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.NoError(t, remade.Ping(context.Background()).Err())
+}
+
+func TestProvideDefaultClient_noConfig(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	client, err := provideDefaultClient(redisOut.Maker)
+	assert.NoError(t, err)
+	assert.NotNil(t, client)
+	assert.Equal(t, envDefaultRedisAddrs[0], client.(*redis.Client).Options().Addr)
+}
+
+func TestFactory_closeTwice(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+	})
+
+	client, err := provideDefaultClient(redisOut.Maker)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Close())
+	assert.NotPanics(t, cleanup)
+	assert.NotPanics(t, cleanup)
+	assert.Empty(t, redisOut.Factory.List())
+
+	client, err = redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	pair := redisOut.Factory.List()["default"]
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pair.Closer()
+		}()
+	}
+	wg.Wait()
+	redisOut.Factory.Close()
+	assert.Equal(t, redis.ErrClosed, client.Ping(context.Background()).Err())
+}
+
+func TestFactory_validate(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf: config.MapAdapter{"redis": map[string]RedisUniversalOptions{
+			"default":     {},
+			"alternative": {Addrs: envDefaultRedisAddrs, Validate: true},
+			"unreachable": {Addrs: []string{"127.0.0.1:1"}, Validate: true},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	def, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.NoError(t, def.Close())
+	again, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	assert.Same(t, def, again)
+
+	alt, err := redisOut.Factory.Make("alternative")
+	assert.NoError(t, err)
+	again, err = redisOut.Factory.Make("alternative")
+	assert.NoError(t, err)
+	assert.Same(t, alt, again)
+	assert.NoError(t, alt.Close())
+	again, err = redisOut.Factory.Make("alternative")
+	assert.NoError(t, err)
+	assert.NotSame(t, alt, again)
+	assert.NoError(t, again.Ping(context.Background()).Err())
+
+	// A failed ping alone doesn't replace the client.
+	unreachable, err := redisOut.Factory.Make("unreachable")
+	assert.NoError(t, err)
+	again, err = redisOut.Factory.Make("unreachable")
+	assert.NoError(t, err)
+	assert.Same(t, unreachable, again)
+}
+
+func TestFactory_PoolStats(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	client, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, client.Ping(context.Background()).Err())
+	}
+	stats, err := redisOut.Factory.PoolStats("default")
+	assert.NoError(t, err)
+	assert.NotZero(t, stats.TotalConns)
+	assert.NotZero(t, stats.Hits)
+}
+
+func TestFactory_CheckHealth(t *testing.T) {
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf: config.MapAdapter{"redis": map[string]RedisUniversalOptions{
+			"default": {},
+			"unreachable": {
+				Addrs: []string{"127.0.0.1:1"},
+			},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, redisOut.Factory.CheckHealth(ctx, "default"))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := redisOut.Factory.CheckHealth(ctx, "unreachable")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unreachable")
+}
+
+func TestNewRedisFactory_remakeWithInterceptor(t *testing.T) {
+	var seen []redis.UniversalOptions
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf: config.MapAdapter{"redis": map[string]RedisUniversalOptions{
+			"default": {},
+		}},
+		Logger: log.NewNopLogger(),
+		Interceptor: func(name string, opts *redis.UniversalOptions) {
+			snapshot := *opts
+			snapshot.Addrs = append([]string(nil), opts.Addrs...)
+			seen = append(seen, snapshot)
+			opts.Addrs[0] = "mutated:6379"
+			opts.Addrs = append(opts.Addrs, "appended:6379")
+			opts.DB++
+		},
+	})
+	defer cleanup()
+
+	_, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	redisOut.Factory.Close()
+	_, err = redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+
+	assert.Len(t, seen, 2)
+	assert.Equal(t, envDefaultRedisAddrs, seen[0].Addrs)
+	assert.Equal(t, seen[0].Addrs, seen[1].Addrs)
+	assert.Equal(t, seen[0].DB, seen[1].DB)
+}
+
 func TestProvideConfigs(t *testing.T) {
 	var r redis.UniversalOptions
 	c := provideConfig()
@@ -0,0 +1,164 @@
+package otredis
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// traceHeaderPrefix marks a payload carrying trace headers. Such a payload is
+// the prefix, the url encoded headers, a line feed and the original payload.
+const traceHeaderPrefix = "#otredis-trace:"
+
+// TracedMessage is a message received by TracedPubSub. Its Payload has the trace
+// headers stripped, and its Context carries the span of the message. The span
+// is owned by the consumer, who must call Finish once the message is handled.
+type TracedMessage struct {
+	*redis.Message
+	Context context.Context
+	span    opentracing.Span
+}
+
+// Finish finishes the span of the message, so that it covers the handling of
+// the message.
+func (m *TracedMessage) Finish() {
+	m.span.Finish()
+}
+
+// TracedPubSub delivers the messages of the subscribed channels, each with a
+// span continuing the trace of the publisher if the payload carries one. See
+// Factory.Subscribe.
+type TracedPubSub struct {
+	pubsub *redis.PubSub
+	ch     chan *TracedMessage
+}
+
+// Subscribe subscribes the client under the provided configuration entry to
+// the channels. The messages are delivered by TracedPubSub.Channel until the
+// context is cancelled or TracedPubSub.Close is called.
+//
+// A span is started for every received message. If the message was published
+// by Factory.Publish, the span continues the trace of the publisher. The
+// context of the message carries the span, so that the consumer can start
+// child spans. The consumer finishes the span with TracedMessage.Finish after
+// handling the message.
+func (r Factory) Subscribe(ctx context.Context, name string, channels ...string) (*TracedPubSub, error) {
+	client, err := r.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	pubsub := client.Subscribe(ctx, channels...)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	t := &TracedPubSub{pubsub: pubsub, ch: make(chan *TracedMessage)}
+	go t.run(ctx, r.tracerOrNoop())
+	return t, nil
+}
+
+// Channel returns the channel of the received messages. It is closed when the
+// subscription ends.
+func (t *TracedPubSub) Channel() <-chan *TracedMessage {
+	return t.ch
+}
+
+// Close ends the subscription.
+func (t *TracedPubSub) Close() error {
+	return t.pubsub.Close()
+}
+
+func (t *TracedPubSub) run(ctx context.Context, tracer opentracing.Tracer) {
+	defer close(t.ch)
+	messages := t.pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = t.pubsub.Close()
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			traced := receive(ctx, tracer, msg)
+			select {
+			case t.ch <- traced:
+			case <-ctx.Done():
+				traced.Finish()
+				_ = t.pubsub.Close()
+				return
+			}
+		}
+	}
+}
+
+// receive strips the trace headers from the message and starts its span.
+func receive(ctx context.Context, tracer opentracing.Tracer, msg *redis.Message) *TracedMessage {
+	var spanContext opentracing.SpanContext
+	if strings.HasPrefix(msg.Payload, traceHeaderPrefix) {
+		if i := strings.IndexByte(msg.Payload, '\n'); i > 0 {
+			values, err := url.ParseQuery(msg.Payload[len(traceHeaderPrefix):i])
+			if err == nil {
+				carrier := make(opentracing.TextMapCarrier)
+				for k := range values {
+					carrier[k] = values.Get(k)
+				}
+				spanContext, _ = tracer.Extract(opentracing.TextMap, carrier)
+				stripped := *msg
+				stripped.Payload = msg.Payload[i+1:]
+				msg = &stripped
+			}
+		}
+	}
+	span := tracer.StartSpan("redis:subscribe", ext.RPCServerOption(spanContext))
+	ext.SpanKind.Set(span, ext.SpanKindConsumerEnum)
+	ext.PeerService.Set(span, "redis")
+	span.SetTag("channel", msg.Channel)
+	return &TracedMessage{Message: msg, Context: opentracing.ContextWithSpan(ctx, span), span: span}
+}
+
+// Publish publishes the payload to the channel with the client under the
+// provided configuration entry. The span context is embedded in the payload,
+// so that the subscribers of Factory.Subscribe continue the trace. The span in
+// the context is used if any. Otherwise, a producer span is started.
+//
+// Whenever a tracer is available, every payload is rewritten as the
+// "#otredis-trace:" prefix, the url encoded trace headers, a line feed and the
+// original payload. Subscribers not using Factory.Subscribe, including those
+// of other applications, receive the rewritten payload, so only publish with
+// it to channels whose subscribers all use Factory.Subscribe. Otherwise, use
+// the client directly.
+func (r Factory) Publish(ctx context.Context, name, channel, payload string) error {
+	client, err := r.Make(name)
+	if err != nil {
+		return err
+	}
+	tracer := r.tracerOrNoop()
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		span = tracer.StartSpan("redis:publish", ext.SpanKindProducer)
+		defer span.Finish()
+		ext.PeerService.Set(span, "redis")
+		span.SetTag("channel", channel)
+	}
+	carrier := make(opentracing.TextMapCarrier)
+	if err := tracer.Inject(span.Context(), opentracing.TextMap, carrier); err != nil || len(carrier) == 0 {
+		return client.Publish(ctx, channel, payload).Err()
+	}
+	values := make(url.Values, len(carrier))
+	for k, v := range carrier {
+		values.Set(k, v)
+	}
+	return client.Publish(ctx, channel, traceHeaderPrefix+values.Encode()+"\n"+payload).Err()
+}
+
+func (r Factory) tracerOrNoop() opentracing.Tracer {
+	if r.tracer == nil {
+		return opentracing.NoopTracer{}
+	}
+	return r.tracer
+}
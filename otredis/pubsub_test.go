@@ -0,0 +1,70 @@
+package otredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory_Subscribe(t *testing.T) {
+	tracer := mocktracer.New()
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+	factory := redisOut.Factory
+	factory.tracer = tracer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pubsub, err := factory.Subscribe(ctx, "default", "test:pubsub")
+	assert.NoError(t, err)
+
+	span, spanCtx := opentracing.StartSpanFromContextWithTracer(context.Background(), tracer, "publisher")
+	assert.NoError(t, factory.Publish(spanCtx, "default", "test:pubsub", "traced"))
+	span.Finish()
+	client, _ := factory.Make("default")
+	assert.NoError(t, client.Publish(context.Background(), "test:pubsub", "plain").Err())
+
+	receive := func() *TracedMessage {
+		select {
+		case msg := <-pubsub.Channel():
+			return msg
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for message")
+			return nil
+		}
+	}
+	traced := receive()
+	assert.Equal(t, "traced", traced.Payload)
+	assert.Equal(t, "test:pubsub", traced.Channel)
+	consumerSpan := opentracing.SpanFromContext(traced.Context).(*mocktracer.MockSpan)
+	assert.Equal(t, span.Context().(mocktracer.MockSpanContext).TraceID, consumerSpan.SpanContext.TraceID)
+	child, _ := opentracing.StartSpanFromContextWithTracer(traced.Context, tracer, "handle")
+	child.Finish()
+	assert.True(t, consumerSpan.FinishTime.IsZero(), "the span is owned by the consumer")
+	traced.Finish()
+	assert.False(t, consumerSpan.FinishTime.IsZero())
+	assert.False(t, consumerSpan.FinishTime.Before(child.(*mocktracer.MockSpan).FinishTime))
+
+	plain := receive()
+	assert.Equal(t, "plain", plain.Payload)
+	plainSpan := opentracing.SpanFromContext(plain.Context).(*mocktracer.MockSpan)
+	assert.NotEqual(t, consumerSpan.SpanContext.TraceID, plainSpan.SpanContext.TraceID)
+	plain.Finish()
+
+	cancel()
+	select {
+	case _, ok := <-pubsub.Channel():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed on cancellation")
+	}
+}
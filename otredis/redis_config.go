@@ -35,6 +35,19 @@ type RedisUniversalOptions struct {
 	IdleTimeout        config.Duration `json:"idleTimeout" yaml:"idleTimeout"`
 	IdleCheckFrequency config.Duration `json:"idleCheckFrequency" yaml:"idleCheckFrequency"`
 
+	// CommandTimeout is the deadline of the commands issued with a context
+	// without deadline. Zero means no deadline. Blocking commands (BLPOP, BRPOP,
+	// BRPOPLPUSH, BLMOVE, BZPOPMIN, BZPOPMAX, and XREAD or XREADGROUP with BLOCK)
+	// are exempt, as are the pipelines containing one, so that they can wait for
+	// as long as they ask.
+	CommandTimeout config.Duration `json:"commandTimeout" yaml:"commandTimeout"`
+
+	// Validate pings the cached client on every Make, and replaces it if it has
+	// been closed. Clients failing the ping for other reasons are kept, as they
+	// reconnect on their own. It costs a round trip per Make, so it is disabled
+	// by default.
+	Validate bool `json:"validate" yaml:"validate"`
+
 	// Only cluster clients.
 
 	MaxRedirects   int  `json:"maxRedirects" yaml:"maxRedirects"`
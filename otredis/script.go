@@ -0,0 +1,55 @@
+package otredis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// scriptRegistry remembers the scripts loaded by each client of the Factory.
+type scriptRegistry struct {
+	mu      sync.Mutex
+	scripts map[string]*redis.Script
+}
+
+func newScriptRegistry() *scriptRegistry {
+	return &scriptRegistry{scripts: make(map[string]*redis.Script)}
+}
+
+// LoadScript loads the Lua script into the redis server of the client under the
+// provided configuration entry with SCRIPT LOAD, and returns it for use with the
+// same client. A script is loaded only once per client: loading the same source
+// again returns the registered script.
+//
+// The returned script runs with EVALSHA, and falls back to EVAL if the server
+// doesn't know the script, for example after a restart or a SCRIPT FLUSH.
+//
+//	script, err := factory.LoadScript("default", src)
+//	res, err := script.Run(ctx, client, keys, args...).Result()
+func (r Factory) LoadScript(name, src string) (*redis.Script, error) {
+	client, err := r.Make(name)
+	if err != nil {
+		return nil, err
+	}
+	script := redis.NewScript(src)
+	key := name + ":" + script.Hash()
+	if r.scripts != nil {
+		r.scripts.mu.Lock()
+		registered, ok := r.scripts.scripts[key]
+		r.scripts.mu.Unlock()
+		if ok {
+			return registered, nil
+		}
+	}
+	if err := script.Load(context.Background(), client).Err(); err != nil {
+		return nil, fmt.Errorf("unable to load script into redis %s: %w", name, err)
+	}
+	if r.scripts != nil {
+		r.scripts.mu.Lock()
+		r.scripts.scripts[key] = script
+		r.scripts.mu.Unlock()
+	}
+	return script, nil
+}
@@ -0,0 +1,82 @@
+package otredis
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHook records the names of the commands sent to redis, except the
+// pings validating the clients.
+type recordingHook struct {
+	mu    *sync.Mutex
+	names *[]string
+}
+
+func (h recordingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	if cmd.Name() == "ping" {
+		return ctx, nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.names = append(*h.names, cmd.Name())
+	return ctx, nil
+}
+
+func (h recordingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h recordingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h recordingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+func TestFactory_LoadScript(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		names []string
+	)
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf:   config.MapAdapter{"redis": map[string]RedisUniversalOptions{"default": {}}},
+		Logger: log.NewNopLogger(),
+		Decorator: func(name string, client redis.UniversalClient) redis.UniversalClient {
+			client.AddHook(recordingHook{mu: &mu, names: &names})
+			return client
+		},
+	})
+	defer cleanup()
+	client, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	const src = `return ARGV[1]`
+	script, err := redisOut.Factory.LoadScript("default", src)
+	assert.NoError(t, err)
+	again, err := redisOut.Factory.LoadScript("default", src)
+	assert.NoError(t, err)
+	assert.Same(t, script, again)
+
+	for i := 0; i < 2; i++ {
+		res, err := script.Run(ctx, client, nil, "foo").Result()
+		assert.NoError(t, err)
+		assert.Equal(t, "foo", res)
+	}
+	assert.Equal(t, []string{"script", "evalsha", "evalsha"}, names)
+
+	// falls back to EVAL once the script is gone.
+	names = nil
+	assert.NoError(t, client.ScriptFlush(ctx).Err())
+	res, err := script.Run(ctx, client, nil, "bar").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", res)
+	assert.Equal(t, []string{"script", "evalsha", "eval"}, names)
+}
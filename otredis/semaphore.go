@@ -0,0 +1,185 @@
+package otredis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/log"
+	"github.com/rs/xid"
+)
+
+// acquireScript removes stale holders from the sorted set and then admits the
+// new holder if the set still has room. It returns 1 on success and 0 if the
+// semaphore is full.
+var acquireScript = redis.NewScript(`
+redis.call("zremrangebyscore", KEYS[1], "-inf", ARGV[1] - ARGV[2])
+if redis.call("zcard", KEYS[1]) < tonumber(ARGV[3]) then
+    redis.call("zadd", KEYS[1], ARGV[1], ARGV[4])
+    redis.call("pexpire", KEYS[1], ARGV[2])
+    return 1
+end
+return 0
+`)
+
+// refreshScript bumps the timestamp of a holder, provided the holder has not
+// been evicted as stale in the meantime.
+var refreshScript = redis.NewScript(`
+if redis.call("zscore", KEYS[1], ARGV[2]) then
+    redis.call("zadd", KEYS[1], ARGV[1], ARGV[2])
+    redis.call("pexpire", KEYS[1], ARGV[3])
+    return 1
+end
+return 0
+`)
+
+// Semaphore is a distributed counting semaphore backed by a redis sorted set.
+// Each holder is a member of the set scored by the last time it was seen alive.
+// Holders that have not been refreshed within the stale timeout are considered
+// crashed and are evicted by the next Acquire.
+//
+// While a holder keeps the permit, a background goroutine refreshes its score
+// every third of the stale timeout, so long-running holders are not evicted.
+// The stale timeout must therefore be comfortably larger than the clock skew
+// between instances.
+//
+// The semaphore makes no fairness guarantee. Waiters poll redis, and whoever
+// polls first after a permit is released gets it.
+type Semaphore struct {
+	client       redis.UniversalClient
+	key          string
+	limit        int
+	staleTimeout time.Duration
+	pollInterval time.Duration
+	tracer       opentracing.Tracer
+}
+
+// SemaphoreOption is the type of the options to config *Semaphore.
+type SemaphoreOption func(semaphore *Semaphore)
+
+// WithSemaphoreStaleTimeout is an option that configures how long a holder can
+// go without refreshing before it is considered crashed. Defaults to 30s.
+func WithSemaphoreStaleTimeout(duration time.Duration) SemaphoreOption {
+	return func(semaphore *Semaphore) {
+		semaphore.staleTimeout = duration
+	}
+}
+
+// WithSemaphorePollInterval is an option that configures how often a blocked
+// Acquire retries. Defaults to 100ms.
+func WithSemaphorePollInterval(duration time.Duration) SemaphoreOption {
+	return func(semaphore *Semaphore) {
+		semaphore.pollInterval = duration
+	}
+}
+
+// WithSemaphoreTracer is an option that configures the tracer used to create
+// acquire spans. Defaults to opentracing.GlobalTracer().
+func WithSemaphoreTracer(tracer opentracing.Tracer) SemaphoreOption {
+	return func(semaphore *Semaphore) {
+		semaphore.tracer = tracer
+	}
+}
+
+const (
+	defaultSemaphoreStaleTimeout = 30 * time.Second
+	defaultSemaphorePollInterval = 100 * time.Millisecond
+	// minSemaphoreStaleTimeout keeps the refresh interval, a third of the stale
+	// timeout, at one millisecond or more, the resolution of the scores.
+	minSemaphoreStaleTimeout = 3 * time.Millisecond
+)
+
+// NewSemaphore creates a *Semaphore that allows at most limit concurrent holders
+// of the given key. A limit lower than 1 is raised to 1. A stale timeout lower
+// than 3ms, or a poll interval that isn't positive, is replaced by its default.
+func NewSemaphore(client redis.UniversalClient, key string, limit int, opts ...SemaphoreOption) *Semaphore {
+	semaphore := &Semaphore{
+		client:       client,
+		key:          key,
+		limit:        limit,
+		staleTimeout: defaultSemaphoreStaleTimeout,
+		pollInterval: defaultSemaphorePollInterval,
+		tracer:       opentracing.GlobalTracer(),
+	}
+	for _, f := range opts {
+		f(semaphore)
+	}
+	if semaphore.limit < 1 {
+		semaphore.limit = 1
+	}
+	if semaphore.staleTimeout < minSemaphoreStaleTimeout {
+		semaphore.staleTimeout = defaultSemaphoreStaleTimeout
+	}
+	if semaphore.pollInterval <= 0 {
+		semaphore.pollInterval = defaultSemaphorePollInterval
+	}
+	return semaphore
+}
+
+// Acquire blocks until a permit is available or the context is done. On
+// success, the returned release function must be called to give the permit
+// back. Calling release more than once is safe.
+func (s *Semaphore) Acquire(ctx context.Context) (release func(), err error) {
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, s.tracer, "redis:semaphore:acquire")
+	defer span.Finish()
+	span.SetTag("semaphore.key", s.key)
+	span.SetTag("semaphore.limit", s.limit)
+
+	id := xid.New().String()
+	for {
+		ok, err := s.tryAcquire(ctx, id)
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(log.Error(err))
+			return nil, fmt.Errorf("unable to acquire semaphore %s: %w", s.key, err)
+		}
+		if ok {
+			return s.hold(id), nil
+		}
+		select {
+		case <-ctx.Done():
+			ext.Error.Set(span, true)
+			span.LogFields(log.Error(ctx.Err()))
+			return nil, fmt.Errorf("unable to acquire semaphore %s: %w", s.key, ctx.Err())
+		case <-time.After(s.pollInterval):
+		}
+	}
+}
+
+func (s *Semaphore) tryAcquire(ctx context.Context, id string) (bool, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	stale := s.staleTimeout.Milliseconds()
+	res, err := acquireScript.Run(ctx, s.client, []string{s.key}, now, stale, s.limit, id).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// hold keeps the permit alive until the returned release function is called.
+func (s *Semaphore) hold(id string) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.staleTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				now := time.Now().UnixNano() / int64(time.Millisecond)
+				refreshScript.Run(ctx, s.client, []string{s.key}, now, id, s.staleTimeout.Milliseconds())
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+		s.client.ZRem(context.Background(), s.key, id)
+	}
+}
@@ -0,0 +1,75 @@
+package otredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphore_Acquire(t *testing.T) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: envDefaultRedisAddrs})
+	defer client.Close()
+	defer client.Del(context.Background(), "test:semaphore")
+
+	semaphore := NewSemaphore(client, "test:semaphore", 2, WithSemaphorePollInterval(time.Millisecond))
+
+	release1, err := semaphore.Acquire(context.Background())
+	assert.NoError(t, err)
+	release2, err := semaphore.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = semaphore.Acquire(ctx)
+	assert.Error(t, err)
+
+	release1()
+	release3, err := semaphore.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	release2()
+	release3()
+	release3()
+}
+
+func TestSemaphore_StaleHolder(t *testing.T) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: envDefaultRedisAddrs})
+	defer client.Close()
+	defer client.Del(context.Background(), "test:semaphore:stale")
+
+	// simulates a crashed holder
+	client.ZAdd(context.Background(), "test:semaphore:stale", &redis.Z{
+		Score:  float64(time.Now().Add(-time.Minute).UnixNano() / int64(time.Millisecond)),
+		Member: "crashed",
+	})
+
+	semaphore := NewSemaphore(client, "test:semaphore:stale", 1, WithSemaphoreStaleTimeout(time.Second))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err := semaphore.Acquire(ctx)
+	assert.NoError(t, err)
+	release()
+}
+
+func TestNewSemaphore_invalidOptions(t *testing.T) {
+	client := redis.NewUniversalClient(&redis.UniversalOptions{Addrs: envDefaultRedisAddrs})
+	defer client.Close()
+	defer client.Del(context.Background(), "test:semaphore-invalid")
+
+	semaphore := NewSemaphore(client, "test:semaphore-invalid", 0,
+		WithSemaphoreStaleTimeout(0),
+		WithSemaphorePollInterval(-time.Second),
+	)
+	assert.Equal(t, 1, semaphore.limit)
+	assert.Equal(t, defaultSemaphoreStaleTimeout, semaphore.staleTimeout)
+	assert.Equal(t, defaultSemaphorePollInterval, semaphore.pollInterval)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err := semaphore.Acquire(ctx)
+	assert.NoError(t, err)
+	release()
+}
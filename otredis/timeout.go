@@ -0,0 +1,89 @@
+package otredis
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type cancelKey struct{}
+
+// timeoutHook is a redis.Hook that sets a deadline on the commands issued with a
+// context without deadline, so that a hung command doesn't block forever.
+// Deadlines set by the caller are left untouched, and so are the blocking
+// commands, which may legitimately wait longer than the timeout.
+type timeoutHook struct {
+	timeout time.Duration
+}
+
+// blockingCommands are the commands that wait for data, up to their own timeout
+// argument, or forever.
+var blockingCommands = map[string]struct{}{
+	"blpop":      {},
+	"brpop":      {},
+	"brpoplpush": {},
+	"blmove":     {},
+	"bzpopmin":   {},
+	"bzpopmax":   {},
+}
+
+// isBlocking reports whether the command may block. XREAD and XREADGROUP only
+// block with the BLOCK option.
+func isBlocking(cmd redis.Cmder) bool {
+	name := cmd.Name()
+	if _, ok := blockingCommands[name]; ok {
+		return true
+	}
+	if name != "xread" && name != "xreadgroup" {
+		return false
+	}
+	for _, arg := range cmd.Args() {
+		if s, ok := arg.(string); ok && strings.EqualFold(s, "block") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h timeoutHook) withTimeout(ctx context.Context, cmds ...redis.Cmder) context.Context {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx
+	}
+	for _, cmd := range cmds {
+		if isBlocking(cmd) {
+			return ctx
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	return context.WithValue(ctx, cancelKey{}, cancel)
+}
+
+func (h timeoutHook) cancel(ctx context.Context) {
+	if cancel, ok := ctx.Value(cancelKey{}).(context.CancelFunc); ok {
+		cancel()
+	}
+}
+
+// BeforeProcess implements redis.Hook.
+func (h timeoutHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return h.withTimeout(ctx, cmd), nil
+}
+
+// AfterProcess implements redis.Hook.
+func (h timeoutHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.cancel(ctx)
+	return nil
+}
+
+// BeforeProcessPipeline implements redis.Hook.
+func (h timeoutHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return h.withTimeout(ctx, cmds...), nil
+}
+
+// AfterProcessPipeline implements redis.Hook.
+func (h timeoutHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	h.cancel(ctx)
+	return nil
+}
@@ -0,0 +1,97 @@
+package otredis
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DoNewsCode/core/config"
+	"github.com/go-kit/kit/log"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+// hangingServer accepts connections but never replies.
+func hangingServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestTimeoutHook(t *testing.T) {
+	addr := hangingServer(t)
+	redisOut, cleanup := provideRedisFactory(in{
+		Conf: config.MapAdapter{"redis": map[string]RedisUniversalOptions{
+			"default": {
+				Addrs:          []string{addr},
+				MaxRetries:     -1,
+				CommandTimeout: config.Duration{Duration: 50 * time.Millisecond},
+			},
+		}},
+		Logger: log.NewNopLogger(),
+	})
+	defer cleanup()
+	client, err := redisOut.Factory.Make("default")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.Error(t, client.Get(context.Background(), "foo").Err())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	// a shorter deadline of the caller is kept.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	assert.Error(t, client.Get(ctx, "foo").Err())
+	assert.Less(t, int64(time.Since(start)), int64(50*time.Millisecond))
+
+	pipe := client.Pipeline()
+	pipe.Get(context.Background(), "foo")
+	start = time.Now()
+	_, err = pipe.Exec(context.Background())
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestTimeoutHook_blocking(t *testing.T) {
+	ctx := context.Background()
+	hook := timeoutHook{timeout: time.Second}
+	cases := []struct {
+		name     string
+		cmd      redis.Cmder
+		blocking bool
+	}{
+		{"get", redis.NewStringCmd(ctx, "get", "foo"), false},
+		{"blpop", redis.NewStringSliceCmd(ctx, "blpop", "foo", 0), true},
+		{"bzpopmax", redis.NewZWithKeyCmd(ctx, "bzpopmax", "foo", 0), true},
+		{"xread", redis.NewXStreamSliceCmd(ctx, "xread", "streams", "foo", "0"), false},
+		{"xread block", redis.NewXStreamSliceCmd(ctx, "xread", "block", 0, "streams", "foo", "0"), true},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			ctx, _ := hook.BeforeProcess(ctx, c.cmd)
+			_, ok := ctx.Deadline()
+			assert.Equal(t, !c.blocking, ok)
+			assert.NoError(t, hook.AfterProcess(ctx, c.cmd))
+
+			ctx, _ = hook.BeforeProcessPipeline(context.Background(), []redis.Cmder{redis.NewStatusCmd(ctx, "ping"), c.cmd})
+			_, ok = ctx.Deadline()
+			assert.Equal(t, !c.blocking, ok)
+			assert.NoError(t, hook.AfterProcessPipeline(ctx, nil))
+		})
+	}
+}
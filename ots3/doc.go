@@ -14,6 +14,48 @@ Creating a s3 manager:
 	var manager = NewManager(accessKey, accessSecret, endpoint, region, bucket)
 	url, err := manager.Upload(context.Background(), "myfile", file)
 
+Uploaded objects can be downloaded or deleted by name. Note the name includes
+the extension Upload may have appended:
+
+	err = manager.Download(context.Background(), "myfile.png", writer)
+	err = manager.Delete(context.Background(), "myfile.png")
+
+Objects can be enumerated by prefix with List, or streamed with ListChan for
+very large buckets.
+
+Presigned URLs
+
+To let clients download or upload private objects directly, without proxying
+through the service, generate time-limited signed urls:
+
+	url, err := manager.PresignGet(context.Background(), "myfile.png", 15*time.Minute)
+	url, err := manager.PresignPut(context.Background(), "myfile.png", 15*time.Minute)
+
+Large Files
+
+Upload streams the reader. Readers larger than one part are sent with a
+multipart upload, buffering one part per concurrent upload, and smaller ones
+with a single PutObject. Tune the part size and the concurrency to trade memory
+for throughput:
+
+	var manager = NewManager(accessKey, accessSecret, endpoint, region, bucket,
+		WithUploadPartSize(16*1024*1024), WithUploadConcurrency(4))
+
+Per-call Options
+
+A single Manager can work across buckets. Pass WithBucket to override the bucket
+of one operation. Uploads take the per-call options through UploadWithOptions:
+
+	url, err := manager.UploadWithOptions(context.Background(), "myfile", file, ots3.WithBucket("other"))
+
+Integrity
+
+Use WithChecksumValidation to verify uploads against the checksum reported by
+the backend. Multipart uploads are verified with the composite ETag, see
+ComputeETag for details.
+
+	var manager = NewManager(accessKey, accessSecret, endpoint, region, bucket, WithChecksumValidation(true))
+
 Integration
 
 Package ots3 exports the following configuration:
@@ -48,7 +90,7 @@ mods3.Maker to factory a *ots3.Manager with a specific configuration entry.
 
 Future scope
 
-Currently this package only focus on the file upload aspect of s3. Other s3 features can be
-incrementally implemented.
+Currently this package focuses on uploading, downloading and deleting files. Other s3 features
+can be incrementally implemented.
 */
 package ots3
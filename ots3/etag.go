@@ -0,0 +1,118 @@
+package ots3
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrChecksumMismatch is returned when the checksum reported by the S3 backend
+// doesn't match the one computed locally from the uploaded bytes.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// ComputeETag computes the ETag a S3 compatible backend assigns to an
+// unencrypted object uploaded by s3manager with the given part size.
+//
+// Objects smaller than the part size are uploaded with a single PutObject, and
+// their ETag is the hex encoded MD5 of the content. Otherwise, the object is
+// uploaded in parts, and the ETag is the hex encoded MD5 of the concatenated
+// binary MD5 of every part, followed by a dash and the number of parts:
+//
+//	hex(md5(md5(part1) + md5(part2) + ... + md5(partN))) + "-" + N
+//
+// This scheme is shared by AWS S3, MinIO and Ceph. Objects encrypted with
+// SSE-KMS or SSE-C have opaque ETags, and cannot be verified this way.
+func ComputeETag(reader io.Reader, partSize int64) (string, error) {
+	h := newETagHasher(partSize)
+	if _, err := io.Copy(h, reader); err != nil {
+		return "", errors.Wrap(err, "unable to compute etag")
+	}
+	return h.ETag(), nil
+}
+
+// etagHasher is an io.Writer that computes the S3 ETag and the SHA256 checksum
+// of everything written to it.
+type etagHasher struct {
+	partSize int64
+	written  int64
+	part     hash.Hash
+	sums     []byte
+	parts    int
+	sha      hash.Hash
+}
+
+func newETagHasher(partSize int64) *etagHasher {
+	return &etagHasher{
+		partSize: partSize,
+		part:     md5.New(),
+		sha:      sha256.New(),
+	}
+}
+
+// Write implements io.Writer.
+func (e *etagHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	e.sha.Write(p)
+	for len(p) > 0 {
+		room := e.partSize - e.written
+		if int64(len(p)) < room {
+			e.part.Write(p)
+			e.written += int64(len(p))
+			break
+		}
+		e.part.Write(p[:room])
+		p = p[room:]
+		e.sums = e.part.Sum(e.sums)
+		e.parts++
+		e.part.Reset()
+		e.written = 0
+	}
+	return n, nil
+}
+
+// ETag returns the expected ETag, without the surrounding quotes.
+func (e *etagHasher) ETag() string {
+	if e.parts == 0 {
+		return hex.EncodeToString(e.part.Sum(nil))
+	}
+	sums, parts := e.sums, e.parts
+	if e.written > 0 {
+		sums = e.part.Sum(sums)
+		parts++
+	}
+	digest := md5.Sum(sums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(digest[:]), parts)
+}
+
+// SHA256 returns the base64 encoded SHA256 checksum of the whole content, in
+// the format of the x-amz-checksum-sha256 header.
+func (e *etagHasher) SHA256() string {
+	return base64.StdEncoding.EncodeToString(e.sha.Sum(nil))
+}
+
+// verify compares the checksums reported by the backend with the local ones.
+// The x-amz-checksum-sha256 header is preferred when the backend returns one
+// for the full object. Otherwise the ETag is compared.
+func (e *etagHasher) verify(etag, checksumSHA256 string) error {
+	if checksumSHA256 != "" && !strings.Contains(checksumSHA256, "-") {
+		if checksumSHA256 != e.SHA256() {
+			return errors.Wrapf(ErrChecksumMismatch, "expected sha256 %s, got %s", e.SHA256(), checksumSHA256)
+		}
+		return nil
+	}
+	etag = strings.Trim(etag, `"`)
+	if etag == "" {
+		return nil
+	}
+	if etag != e.ETag() {
+		return errors.Wrapf(ErrChecksumMismatch, "expected etag %s, got %s", e.ETag(), etag)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package ots3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeETag(t *testing.T) {
+	t.Parallel()
+
+	md5Hex := func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	}
+	composite := func(parts ...[]byte) string {
+		var sums []byte
+		for _, part := range parts {
+			sum := md5.Sum(part)
+			sums = append(sums, sum[:]...)
+		}
+		return fmt.Sprintf("%s-%d", md5Hex(sums), len(parts))
+	}
+
+	cases := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"empty", []byte{}, md5Hex([]byte{})},
+		{"single", []byte("foo"), md5Hex([]byte("foo"))},
+		{"exact", []byte("foobar"), composite([]byte("foobar"))},
+		{"multiple", []byte("foobarbaz"), composite([]byte("foobar"), []byte("baz"))},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			etag, err := ComputeETag(bytes.NewReader(c.content), 6)
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, etag)
+		})
+	}
+}
+
+func TestETagHasher_Verify(t *testing.T) {
+	t.Parallel()
+
+	h := newETagHasher(3)
+	h.Write([]byte("foo"))
+	h.Write([]byte("ba"))
+	h.Write([]byte("r"))
+
+	assert.NoError(t, h.verify(`"`+h.ETag()+`"`, ""))
+	assert.NoError(t, h.verify("", h.SHA256()))
+	assert.NoError(t, h.verify(h.ETag(), "composite-2"))
+	assert.True(t, errors.Is(h.verify("bad", ""), ErrChecksumMismatch))
+	assert.True(t, errors.Is(h.verify(h.ETag(), "bad"), ErrChecksumMismatch))
+}
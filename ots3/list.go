@@ -0,0 +1,103 @@
+package ots3
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+)
+
+// ObjectInfo describes an object listed by List or ListChan.
+type ObjectInfo struct {
+	// Name is the name of the object, as accepted by Download and Delete.
+	Name string
+	// Key is the full object key, including the path prefix and the keyer.
+	Key          string
+	Size         int64
+	LastModified time.Time
+	// Err is only set on the last value sent by ListChan, if the listing failed.
+	Err error
+}
+
+// List returns every object whose name starts with prefix. The prefix is mapped
+// to the object key the same way as Upload. Continuation tokens are followed
+// transparently, so prefer ListChan for very large buckets.
+func (m *Manager) List(ctx context.Context, prefix string, opts ...CallOption) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := m.list(ctx, prefix, opts, func(object ObjectInfo) bool {
+		objects = append(objects, object)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// ListChan is like List, but streams the objects page by page. The channel is
+// closed when every object is sent, or when the context is canceled. If the
+// listing fails, the last value sent carries the error.
+func (m *Manager) ListChan(ctx context.Context, prefix string, opts ...CallOption) <-chan ObjectInfo {
+	ch := make(chan ObjectInfo)
+	go func() {
+		defer close(ch)
+		err := m.list(ctx, prefix, opts, func(object ObjectInfo) bool {
+			select {
+			case ch <- object:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		if err != nil {
+			select {
+			case ch <- ObjectInfo{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return ch
+}
+
+// list calls fn with every object under prefix, until fn returns false.
+func (m *Manager) list(ctx context.Context, prefix string, opts []CallOption, fn func(ObjectInfo) bool) error {
+	c := m.callConfig(opts)
+	base := m.objectKey("")
+	k := m.objectKey(prefix)
+	span, ctx := m.startSpan(ctx, "ots3.List", c.bucket, k)
+	defer span.Finish()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var (
+		count    int
+		listFunc = func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, object := range page.Contents {
+				count++
+				if !fn(ObjectInfo{
+					Name:         strings.TrimPrefix(aws.StringValue(object.Key), base),
+					Key:          aws.StringValue(object.Key),
+					Size:         aws.Int64Value(object.Size),
+					LastModified: aws.TimeValue(object.LastModified),
+				}) {
+					return false
+				}
+			}
+			return true
+		}
+	)
+	err := s3.New(m.sess).ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(k),
+	}, listFunc)
+	span.SetTag("s3.objects", count)
+	if err != nil {
+		ext.Error.Set(span, true)
+		return errors.Wrap(err, "unable to list objects")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package ots3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const listPage = `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult>
+	<IsTruncated>%t</IsTruncated>
+	<NextContinuationToken>%s</NextContinuationToken>
+	<Contents>
+		<Key>prefix/%s</Key>
+		<Size>%d</Size>
+		<LastModified>2021-01-02T03:04:05.000Z</LastModified>
+	</Contents>
+</ListBucketResult>`
+
+func TestManager_List(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "prefix/report", request.URL.Query().Get("prefix"))
+		if request.URL.Query().Get("continuation-token") == "" {
+			_, _ = fmt.Fprintf(writer, listPage, true, "next", "report-1.txt", 1)
+			return
+		}
+		_, _ = fmt.Fprintf(writer, listPage, false, "", "report-2.txt", 2)
+	}))
+	defer server.Close()
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar", WithPathPrefix("prefix/"))
+
+	objects, err := m.List(context.Background(), "report")
+	assert.NoError(t, err)
+	assert.Equal(t, []ObjectInfo{
+		{Name: "report-1.txt", Key: "prefix/report-1.txt", Size: 1, LastModified: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Name: "report-2.txt", Key: "prefix/report-2.txt", Size: 2, LastModified: time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}, objects)
+
+	var names []string
+	for object := range m.ListChan(context.Background(), "report") {
+		assert.NoError(t, object.Err)
+		names = append(names, object.Name)
+	}
+	assert.Equal(t, []string{"report-1.txt", "report-2.txt"}, names)
+}
+
+func TestManager_ListChanError(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar")
+
+	var objects []ObjectInfo
+	for object := range m.ListChan(context.Background(), "") {
+		objects = append(objects, object)
+	}
+	assert.Len(t, objects, 1)
+	assert.Error(t, objects[0].Err)
+}
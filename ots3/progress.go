@@ -0,0 +1,59 @@
+package ots3
+
+import "io"
+
+// WithProgress is a CallOption that reports the progress of an upload. The
+// callback receives the number of bytes read from the reader so far, and the
+// total size, or -1 if the size can't be known in advance. Readers having a Len
+// method, such as *bytes.Reader or *strings.Reader, or implementing io.Seeker,
+// have a known size. Multipart uploads report the bytes of every part, as they
+// are handed over to the upload.
+//
+// The callback is called from the goroutine calling Upload, so guard any state
+// shared with other goroutines. It should return quickly, as it blocks the
+// upload.
+func WithProgress(progress func(bytesTransferred, total int64)) CallOption {
+	return func(c *callConfig) {
+		c.progress = progress
+	}
+}
+
+// progressReader reports the bytes read through it.
+type progressReader struct {
+	reader      io.Reader
+	transferred int64
+	total       int64
+	progress    func(bytesTransferred, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.reader.Read(b)
+	if n > 0 {
+		p.transferred += int64(n)
+		p.progress(p.transferred, p.total)
+	}
+	return n, err
+}
+
+// readerSize returns the remaining size of the reader, or -1 if unknown.
+func readerSize(reader io.Reader) int64 {
+	switch r := reader.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case io.Seeker:
+		current, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := r.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := r.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	default:
+		return -1
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/DoNewsCode/core/contract"
 	"github.com/DoNewsCode/core/key"
@@ -30,8 +32,16 @@ type Manager struct {
 	doer          contract.HttpDoer
 	pathPrefix    string
 	keyer         contract.Keyer
-	locationFunc  func(location string) (url string)
+	urlBuilder    func(bucket, key, location string) (url string)
 	autoExtension bool
+	checksum      bool
+	partSize      int64
+	concurrency   int
+	sse           string
+	sseKMSKeyID   string
+	timeout       time.Duration
+	fetchTimeout  time.Duration
+	slots         chan struct{}
 }
 
 // Config contains a various of configurations for Manager. It is mean to be modified by Option.
@@ -45,8 +55,17 @@ type Config struct {
 	doer          contract.HttpDoer
 	keyer         contract.Keyer
 	pathPrefix    string
-	locationFunc  func(location string) (url string)
+	urlBuilder    func(bucket, key, location string) (url string)
 	autoExtension bool
+	checksum      bool
+	partSize      int64
+	concurrency   int
+	sse           string
+	sseKMSKeyID   string
+	timeout       time.Duration
+	fetchTimeout  time.Duration
+	maxRetries    int
+	maxUploads    int
 }
 
 // Option is the type of functional options to alter Config.
@@ -82,9 +101,27 @@ func WithHttpClient(client contract.HttpDoer) Option {
 
 // WithLocationFunc is an option that decides the how url is mapped to S3 bucket and path.
 // Useful when not serving file directly from S3, but from a CDN.
+// It is a shorthand of WithURLBuilder ignoring the bucket and the key.
 func WithLocationFunc(f func(location string) (url string)) Option {
 	return func(c *Config) {
-		c.locationFunc = f
+		c.urlBuilder = func(bucket, key, location string) (url string) {
+			return f(location)
+		}
+	}
+}
+
+// WithURLBuilder is an option that builds the url returned by uploads, from the
+// bucket, the object key and the location reported by S3. Unlike
+// WithLocationFunc, it doesn't need to parse the location to build urls
+// embedding the key, for example CDN urls or signed urls. It replaces the
+// function set by WithLocationFunc, and vice versa.
+//
+//	WithURLBuilder(func(bucket, key, location string) string {
+//		return "https://cdn.example.com/" + bucket + "/" + key
+//	})
+func WithURLBuilder(f func(bucket, key, location string) (url string)) Option {
+	return func(c *Config) {
+		c.urlBuilder = f
 	}
 }
 
@@ -95,15 +132,165 @@ func WithAutoExtension(auto bool) Option {
 	}
 }
 
+// WithChecksumValidation is an option that verifies the integrity of every
+// upload. The checksums are computed while streaming, and compared against the
+// x-amz-checksum-sha256 header if the backend returns one, or against the ETag
+// otherwise. Multipart ETags are handled, see ComputeETag for the algorithm.
+// Uploads failing the check return an error wrapping ErrChecksumMismatch. When
+// combined with the "aws:kms" server side encryption, the ETag is not compared,
+// as it is not derived from the content, and only the x-amz-checksum-sha256
+// header is checked. Don't enable it for buckets encrypted with SSE-KMS by
+// default, or with SSE-C, for the same reason.
+func WithChecksumValidation(validate bool) Option {
+	return func(c *Config) {
+		c.checksum = validate
+	}
+}
+
+// WithUploadPartSize is an option that sets the size in bytes of the parts of
+// multipart uploads. Readers larger than one part are uploaded in parts, while
+// smaller ones go through a single PutObject. The minimum, also the default, is
+// 5MB.
+func WithUploadPartSize(partSize int64) Option {
+	return func(c *Config) {
+		c.partSize = partSize
+	}
+}
+
+// WithUploadConcurrency is an option that sets the number of parts uploaded in
+// parallel by multipart uploads. Every part in flight is buffered in memory, so
+// the memory needed by an upload is roughly part size times concurrency. The
+// default is 5.
+func WithUploadConcurrency(concurrency int) Option {
+	return func(c *Config) {
+		c.concurrency = concurrency
+	}
+}
+
+// WithServerSideEncryption is an option that asks S3 to encrypt the uploaded
+// objects at rest with the given algorithm, either "AES256" or "aws:kms". With
+// "aws:kms", the key set by WithSSEKMSKeyID is used, or the default KMS key of
+// the bucket if none is set.
+//
+// Note a bucket policy may deny uploads without encryption, or with another
+// algorithm or key, in which case the uploads fail with AccessDenied. Likewise
+// a bucket configured with default encryption encrypts the objects even
+// without this option. The ETag of objects encrypted with SSE-KMS is not
+// derived from the content, so WithChecksumValidation skips it with "aws:kms".
+func WithServerSideEncryption(algorithm string) Option {
+	return func(c *Config) {
+		c.sse = algorithm
+	}
+}
+
+// WithSSEKMSKeyID is an option that sets the KMS key used by the "aws:kms"
+// server side encryption. See WithServerSideEncryption.
+func WithSSEKMSKeyID(id string) Option {
+	return func(c *Config) {
+		c.sseKMSKeyID = id
+	}
+}
+
+// WithMaxRetries is an option that sets the maximum number of retries of the
+// requests to S3, for example against flaky S3 compatible stores. The default
+// is the one of the S3 service client, currently 3.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *Config) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithRequestTimeout is an option that bounds every operation of the Manager,
+// such as an Upload or a Download, including the retries. Note for Download
+// the timeout covers streaming the object to the writer as well. There is no
+// timeout by default.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.timeout = timeout
+	}
+}
+
+// WithFetchTimeout is an option that bounds fetching the source url in
+// UploadFromUrl. As the source is streamed to S3 while it is fetched, the
+// timeout covers the whole upload. There is no timeout by default.
+func WithFetchTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.fetchTimeout = timeout
+	}
+}
+
+// WithMaxConcurrentUploads is an option that limits the number of uploads in
+// flight, across Upload and UploadFromUrl, to protect file descriptors and
+// upstream connections when many uploads are fired at once. Uploads beyond the
+// limit wait for a free slot, or until their context is done. There is no limit
+// by default.
+func WithMaxConcurrentUploads(n int) Option {
+	return func(c *Config) {
+		c.maxUploads = n
+	}
+}
+
+// CallOption is the type of functional options to alter a single operation of
+// the Manager.
+type CallOption func(*callConfig)
+
+type callConfig struct {
+	bucket      string
+	contentType string
+	metadata    map[string]string
+	progress    func(bytesTransferred, total int64)
+}
+
+// WithBucket is a CallOption that runs the operation against the given bucket
+// instead of the one the Manager is constructed with. The bucket becomes part of
+// the location handed over to the function set by WithLocationFunc, so a
+// location function rewriting locations (to a CDN for example) must take every
+// bucket in use into account. WithURLBuilder receives the bucket explicitly.
+// Path prefix and keyer still apply.
+//
+// PresignGet and PresignPut honor it as well: the presigned url points to the
+// object in the given bucket, so the holder of the url reaches that bucket
+// without further credentials.
+func WithBucket(name string) CallOption {
+	return func(c *callConfig) {
+		c.bucket = name
+	}
+}
+
+// WithContentType is a CallOption that sets the Content-Type of the uploaded
+// object, instead of the one detected from the content.
+func WithContentType(contentType string) CallOption {
+	return func(c *callConfig) {
+		c.contentType = contentType
+	}
+}
+
+// WithMetadata is a CallOption that attaches user metadata to the uploaded
+// object. S3 returns it as x-amz-meta-* headers.
+func WithMetadata(metadata map[string]string) CallOption {
+	return func(c *callConfig) {
+		c.metadata = metadata
+	}
+}
+
+func (m *Manager) callConfig(opts []CallOption) callConfig {
+	c := callConfig{bucket: m.bucket}
+	for _, f := range opts {
+		f(&c)
+	}
+	return c
+}
+
 // NewManager creates a new S3 manager
 func NewManager(accessKey, accessSecret, endpoint, region, bucket string, opts ...Option) *Manager {
 	c := &Config{
 		doer:  http.DefaultClient,
 		keyer: key.New(),
-		locationFunc: func(location string) (url string) {
+		urlBuilder: func(bucket, key, location string) (url string) {
 			return location
 		},
 		autoExtension: true,
+		maxRetries:    aws.UseServiceDefaultRetries,
 	}
 	for _, f := range opts {
 		f(c)
@@ -115,6 +302,7 @@ func NewManager(accessKey, accessSecret, endpoint, region, bucket string, opts .
 		Region:           aws.String(region),
 		DisableSSL:       aws.Bool(true),
 		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(c.maxRetries),
 	}
 	sess := session.Must(session.NewSession(s3Config))
 	c.keyer.Key("/")
@@ -125,65 +313,293 @@ func NewManager(accessKey, accessSecret, endpoint, region, bucket string, opts .
 		doer:          c.doer,
 		pathPrefix:    c.pathPrefix,
 		keyer:         c.keyer,
-		locationFunc:  c.locationFunc,
+		urlBuilder:    c.urlBuilder,
 		autoExtension: c.autoExtension,
+		checksum:      c.checksum,
+		partSize:      c.partSize,
+		concurrency:   c.concurrency,
+		sse:           c.sse,
+		sseKMSKeyID:   c.sseKMSKeyID,
+		timeout:       c.timeout,
+		fetchTimeout:  c.fetchTimeout,
+	}
+	if c.maxUploads > 0 {
+		m.slots = make(chan struct{}, c.maxUploads)
 	}
 
 	// add opentracing capabilities if opt in
 	if c.tracer != nil {
-		sess.Handlers.Build.PushFront(m.otHandler())
+		sess.Handlers.Build.PushFrontNamed(request.NamedHandler{Name: otHandlerName, Fn: m.otHandler()})
 	}
 	return m
 }
 
-// Upload uploads an io.reader to the S3 server, and returns the url on S3. The extension of the uploaded file
-// is auto detected.
+// Upload uploads an io.reader to the S3 server, and returns the url on S3. The extension and the
+// Content-Type of the uploaded file are auto detected, unless WithContentType is given. The reader is
+// streamed in parts, so its size doesn't need to be known in advance. The returned url goes through the
+// function set by WithLocationFunc or WithURLBuilder, and the key respects WithPathPrefix and WithKeyer.
+// Use UploadWithOptions to pass per-call options.
 func (m *Manager) Upload(ctx context.Context, name string, reader io.Reader) (newUrl string, err error) {
+	return m.UploadWithOptions(ctx, name, reader)
+}
+
+// UploadWithOptions is like Upload, with per-call options such as WithBucket,
+// WithContentType or WithProgress. It is kept apart from Upload so that the
+// Uploader interface doesn't change.
+func (m *Manager) UploadWithOptions(ctx context.Context, name string, reader io.Reader, opts ...CallOption) (newUrl string, err error) {
+	release, err := m.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	return m.upload(ctx, name, reader, opts...)
+}
 
-	// Create an uploader with the session and default options
-	uploader := s3manager.NewUploader(m.sess)
-	var extension = ""
-	var buf = bytes.NewBuffer(nil)
-	if m.autoExtension {
-		var tee = io.TeeReader(reader, buf)
-		mi, err := mimetype.DetectReader(tee)
-		if err == nil {
+func (m *Manager) upload(ctx context.Context, name string, reader io.Reader, opts ...CallOption) (newUrl string, err error) {
+	c := m.callConfig(opts)
+
+	uploader := s3manager.NewUploader(m.sess, func(u *s3manager.Uploader) {
+		if m.partSize > 0 {
+			u.PartSize = m.partSize
+		}
+		if m.concurrency > 0 {
+			u.Concurrency = m.concurrency
+		}
+	})
+	if c.progress != nil {
+		reader = &progressReader{reader: reader, total: readerSize(reader), progress: c.progress}
+	}
+	var (
+		extension   = ""
+		contentType = c.contentType
+		buf         = bytes.NewBuffer(nil)
+	)
+	if mi, err := mimetype.DetectReader(io.TeeReader(reader, buf)); err == nil {
+		if m.autoExtension {
 			extension = mi.Extension()
 		}
+		if contentType == "" {
+			contentType = mi.String()
+		}
 	}
 
-	k := key.KeepOdd(m.keyer).Key("/", name+extension)
-
 	// Efficiently use the buf for mime type reading and continue from the rest of the body
-	result, err := uploader.UploadWithContext(ctx, &s3manager.UploadInput{
-		Bucket: aws.String(m.bucket),
-		Key:    aws.String(m.pathPrefix + k),
-		Body:   io.MultiReader(buf, reader),
-	})
+	var (
+		body         = io.MultiReader(buf, reader)
+		hasher       *etagHasher
+		checksum     string
+		uploaderOpts []func(*s3manager.Uploader)
+	)
+	if m.checksum {
+		hasher = newETagHasher(uploader.PartSize)
+		body = io.TeeReader(body, hasher)
+		uploaderOpts = append(uploaderOpts, s3manager.WithUploaderRequestOptions(
+			request.WithGetResponseHeader("X-Amz-Checksum-Sha256", &checksum),
+		))
+	}
+	k := m.objectKey(name + extension)
+	span, ctx := m.startSpan(ctx, "ots3.Upload", c.bucket, k)
+	defer span.Finish()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	input := &s3manager.UploadInput{
+		Bucket:   aws.String(c.bucket),
+		Key:      aws.String(k),
+		Body:     body,
+		Metadata: aws.StringMap(c.metadata),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if m.sse != "" {
+		input.ServerSideEncryption = aws.String(m.sse)
+	}
+	if m.sse == s3.ServerSideEncryptionAwsKms && m.sseKMSKeyID != "" {
+		input.SSEKMSKeyId = aws.String(m.sseKMSKeyID)
+	}
+	result, err := uploader.UploadWithContext(ctx, input, uploaderOpts...)
 
 	if err != nil {
+		ext.Error.Set(span, true)
 		return "", errors.Wrap(err, "unable to upload from io reader")
 	}
 
-	return m.locationFunc(result.Location), nil
+	if hasher != nil {
+		// The ETag of objects encrypted with SSE-KMS is not derived from the
+		// content, so only the SHA256 checksum, if any, can be verified.
+		etag := aws.StringValue(result.ETag)
+		if m.sse == s3.ServerSideEncryptionAwsKms {
+			etag = ""
+		}
+		if err := hasher.verify(etag, checksum); err != nil {
+			ext.Error.Set(span, true)
+			return "", errors.Wrap(err, "unable to verify upload")
+		}
+	}
+
+	return m.urlBuilder(c.bucket, k, result.Location), nil
 }
 
 // UploadFromUrl fetches a file from an external url, copy them to the S3 server, and generate a new, local url.
 // It uses streams to relay files (instead of buffering the entire file in memory).
-// it gives the file a random name using the global seed.
-func (m *Manager) UploadFromUrl(ctx context.Context, url string) (newUrl string, err error) {
+// it gives the file a random name using the global seed. The Content-Type of the response is kept, if any.
+func (m *Manager) UploadFromUrl(ctx context.Context, url string, opts ...CallOption) (newUrl string, err error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return "", errors.Wrap(err, "cannot build request")
 	}
-	req.WithContext(ctx)
+	release, err := m.acquire(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if m.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.fetchTimeout)
+		defer cancel()
+	}
+	req = req.WithContext(ctx)
 	resp, err := m.doer.Do(req)
 	if err != nil {
 		return "", errors.Wrap(err, "cannot fetch image")
 	}
 	body := resp.Body
 	defer body.Close()
-	return m.Upload(ctx, randString(16), body)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		opts = append([]CallOption{WithContentType(contentType)}, opts...)
+	}
+	return m.upload(ctx, randString(16), body, opts...)
+}
+
+// acquire waits for an upload slot, if the uploads are limited.
+func (m *Manager) acquire(ctx context.Context) (release func(), err error) {
+	if m.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case m.slots <- struct{}{}:
+		return func() { <-m.slots }, nil
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "unable to acquire an upload slot")
+	}
+}
+
+// Download streams the object under name to the writer. The name is mapped to
+// the object key the same way as Upload, with the path prefix and the keyer, so
+// it must include the extension Upload may have appended.
+func (m *Manager) Download(ctx context.Context, name string, w io.Writer, opts ...CallOption) error {
+	c := m.callConfig(opts)
+	k := m.objectKey(name)
+	span, ctx := m.startSpan(ctx, "ots3.Download", c.bucket, k)
+	defer span.Finish()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	out, err := s3.New(m.sess).GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(k),
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		return errors.Wrap(err, "unable to get object")
+	}
+	defer out.Body.Close()
+	if _, err := io.Copy(w, out.Body); err != nil {
+		ext.Error.Set(span, true)
+		return errors.Wrap(err, "unable to download object")
+	}
+	return nil
+}
+
+// Delete deletes the object under name. The name is mapped to the object key
+// the same way as Upload. Deleting an object that doesn't exist is not an error.
+func (m *Manager) Delete(ctx context.Context, name string, opts ...CallOption) error {
+	c := m.callConfig(opts)
+	k := m.objectKey(name)
+	span, ctx := m.startSpan(ctx, "ots3.Delete", c.bucket, k)
+	defer span.Finish()
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s3.New(m.sess).DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(k),
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		return errors.Wrap(err, "unable to delete object")
+	}
+	return nil
+}
+
+// Copy copies the object under src to dst with a server side copy, so the
+// content doesn't go through the service. Both names are mapped to object keys
+// the same way as Upload, in the bucket of the Manager or the one given by
+// WithBucket. Moving an object is a Copy followed by a Delete.
+func (m *Manager) Copy(ctx context.Context, src, dst string, opts ...CallOption) error {
+	c := m.callConfig(opts)
+	return m.CopyFrom(ctx, c.bucket, src, dst, opts...)
+}
+
+// CopyFrom is like Copy, but copies the object from another bucket. The source
+// name is mapped to the object key the same way as the destination.
+func (m *Manager) CopyFrom(ctx context.Context, srcBucket, src, dst string, opts ...CallOption) error {
+	c := m.callConfig(opts)
+	srcKey := m.objectKey(src)
+	k := m.objectKey(dst)
+	span, ctx := m.startSpan(ctx, "ots3.Copy", c.bucket, k)
+	defer span.Finish()
+	span.SetTag("s3.source", srcBucket+"/"+srcKey)
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := s3.New(m.sess).CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(c.bucket),
+		Key:        aws.String(k),
+		CopySource: aws.String((&url.URL{Path: srcBucket + "/" + srcKey}).EscapedPath()),
+	})
+	if err != nil {
+		ext.Error.Set(span, true)
+		return errors.Wrap(err, "unable to copy object")
+	}
+	return nil
+}
+
+// PresignGet returns a url that downloads the object under name without
+// further credentials until it expires, for example to let browsers fetch
+// private objects directly. The name is mapped to the object key the same way
+// as Upload.
+func (m *Manager) PresignGet(ctx context.Context, name string, expire time.Duration, opts ...CallOption) (string, error) {
+	c := m.callConfig(opts)
+	req, _ := s3.New(m.sess).GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(m.objectKey(name)),
+	})
+	return m.presign(ctx, req, expire)
+}
+
+// PresignPut returns a url that uploads an object under name with a PUT request
+// without further credentials until it expires. The name is mapped to the
+// object key the same way as Upload, except no extension is appended.
+func (m *Manager) PresignPut(ctx context.Context, name string, expire time.Duration, opts ...CallOption) (string, error) {
+	c := m.callConfig(opts)
+	req, _ := s3.New(m.sess).PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(m.objectKey(name)),
+	})
+	return m.presign(ctx, req, expire)
+}
+
+func (m *Manager) presign(ctx context.Context, req *request.Request, expire time.Duration) (string, error) {
+	// the trace headers would otherwise be signed, and required from the client.
+	req.Handlers.Build.RemoveByName(otHandlerName)
+	req.SetContext(ctx)
+	url, err := req.Presign(expire)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to presign request")
+	}
+	return url, nil
 }
 
 // CreateBucket create a buckets in s3 server.
@@ -196,6 +612,35 @@ func (m *Manager) CreateBucket(ctx context.Context, name string) error {
 	return err
 }
 
+// objectKey maps the name to the object key, with the path prefix and the keyer.
+func (m *Manager) objectKey(name string) string {
+	return m.pathPrefix + key.KeepOdd(m.keyer).Key("/", name)
+}
+
+// startSpan starts a span for an operation of the Manager, tagged with the
+// bucket and the key. The requests sent to S3 during the operation are traced
+// as its children.
+func (m *Manager) startSpan(ctx context.Context, operation, bucket, key string) (opentracing.Span, context.Context) {
+	tracer := m.tracer
+	if tracer == nil {
+		tracer = opentracing.NoopTracer{}
+	}
+	span, ctx := opentracing.StartSpanFromContextWithTracer(ctx, tracer, operation)
+	span.SetTag("s3.bucket", bucket)
+	span.SetTag("s3.key", key)
+	return span, ctx
+}
+
+// withTimeout bounds the operation with the request timeout, if any.
+func (m *Manager) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if m.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, m.timeout)
+}
+
+const otHandlerName = "ots3.otHandler"
+
 func (m *Manager) otHandler() func(*request.Request) {
 	tracer := m.tracer
 
@@ -1,11 +1,20 @@
 package ots3
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/DoNewsCode/core/key"
 	"github.com/aws/aws-sdk-go/aws/awserr"
@@ -72,7 +81,332 @@ func TestManager_UploadFromUrl(t *testing.T) {
 	newURL, err := m.UploadFromUrl(context.Background(), "https://avatars.githubusercontent.com/u/43054062")
 	assert.NoError(t, err)
 	assert.NotEmpty(t, newURL)
-	assert.Len(t, tracer.FinishedSpans(), 2)
+	assert.Len(t, tracer.FinishedSpans(), 3)
+}
+
+func TestManager_Upload(t *testing.T) {
+	tracer := mocktracer.New()
+	m := NewManager(
+		envDefaultS3AccessKey,
+		envDefaultS3AccessSecret,
+		envDefaultS3Endpoint,
+		envDefaultS3Region,
+		envDefaultS3Bucket,
+		WithTracer(tracer),
+		WithPathPrefix("prefix/"),
+		WithKeyer(key.New("tenant", "foo")),
+		WithLocationFunc(func(location string) (url string) {
+			return "cdn:" + location
+		}),
+	)
+	newURL, err := m.Upload(context.Background(), "report", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(newURL, "cdn:"))
+	assert.True(t, strings.HasSuffix(newURL, "/prefix/foo/report.txt"))
+	assert.Equal(t, "prefix/foo/report.txt", tracer.FinishedSpans()[len(tracer.FinishedSpans())-1].Tag("s3.key"))
+}
+
+func TestManager_WithBucket(t *testing.T) {
+	m := setupManager()
+	_ = m.CreateBucket(context.Background(), "foo")
+	newURL, err := m.UploadWithOptions(context.Background(), "with-bucket", strings.NewReader("hello"), WithBucket("foo"))
+	assert.NoError(t, err)
+	assert.Contains(t, newURL, "/foo/")
+	assert.NotContains(t, newURL, "/"+envDefaultS3Bucket+"/")
+}
+
+func TestManager_DownloadDelete(t *testing.T) {
+	tracer := mocktracer.New()
+	m := setupManagerWithTracer(tracer)
+	content := strings.Repeat("hello", 1000)
+	_, err := m.Upload(context.Background(), "download", strings.NewReader(content))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.Download(context.Background(), "download.txt", &buf))
+	assert.Equal(t, content, buf.String())
+
+	assert.NoError(t, m.Delete(context.Background(), "download.txt"))
+	assert.Error(t, m.Download(context.Background(), "download.txt", &buf))
+
+	var tagged int
+	for _, span := range tracer.FinishedSpans() {
+		if span.Tag("s3.key") == "download.txt" {
+			assert.Equal(t, envDefaultS3Bucket, span.Tag("s3.bucket"))
+			tagged++
+		}
+	}
+	assert.Equal(t, 4, tagged)
+}
+
+func TestManager_UploadMultipart(t *testing.T) {
+	m := NewManager(
+		envDefaultS3AccessKey,
+		envDefaultS3AccessSecret,
+		envDefaultS3Endpoint,
+		envDefaultS3Region,
+		envDefaultS3Bucket,
+		WithUploadPartSize(5*1024*1024),
+		WithUploadConcurrency(2),
+		WithChecksumValidation(true),
+	)
+	content := bytes.Repeat([]byte("0123456789abcdef"), 20*1024*1024/16)
+	_, err := m.Upload(context.Background(), "multipart", bytes.NewReader(content))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, m.Download(context.Background(), "multipart.txt", &buf))
+	assert.Equal(t, len(content), buf.Len())
+	assert.NoError(t, m.Delete(context.Background(), "multipart.txt"))
+}
+
+// fakeS3 is a minimal S3 server accepting PutObject and CopyObject, recording
+// the request headers.
+func fakeS3(t *testing.T) (*httptest.Server, <-chan http.Header) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = ioutil.ReadAll(request.Body)
+		headers <- request.Header
+		writer.Header().Set("ETag", `"etag"`)
+		if request.Header.Get("X-Amz-Copy-Source") != "" {
+			_, _ = writer.Write([]byte(`<CopyObjectResult><ETag>"etag"</ETag></CopyObjectResult>`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, headers
+}
+
+func TestManager_ContentType(t *testing.T) {
+	t.Parallel()
+	server, headers := fakeS3(t)
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar")
+
+	_, err := m.Upload(context.Background(), "page", strings.NewReader("<html><body>hello</body></html>"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", (<-headers).Get("Content-Type"))
+
+	_, err = m.UploadWithOptions(
+		context.Background(),
+		"page",
+		strings.NewReader("<html></html>"),
+		WithContentType("text/plain"),
+		WithMetadata(map[string]string{"owner": "foo"}),
+	)
+	assert.NoError(t, err)
+	header := <-headers
+	assert.Equal(t, "text/plain", header.Get("Content-Type"))
+	assert.Equal(t, "foo", header.Get("X-Amz-Meta-Owner"))
+}
+
+func TestManager_ServerSideEncryption(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name      string
+		options   []Option
+		algorithm string
+		keyID     string
+	}{
+		{"none", nil, "", ""},
+		{"aes256", []Option{WithServerSideEncryption("AES256"), WithSSEKMSKeyID("ignored")}, "AES256", ""},
+		{"kms default key", []Option{WithServerSideEncryption("aws:kms")}, "aws:kms", ""},
+		{"kms", []Option{WithServerSideEncryption("aws:kms"), WithSSEKMSKeyID("key-id")}, "aws:kms", "key-id"},
+		{"kms with checksum", []Option{WithServerSideEncryption("aws:kms"), WithChecksumValidation(true)}, "aws:kms", ""},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			server, headers := fakeS3(t)
+			m := NewManager("key", "secret", server.URL, "us-east-1", "bar", c.options...)
+			_, err := m.Upload(context.Background(), "secret", strings.NewReader("hello"))
+			assert.NoError(t, err)
+			header := <-headers
+			assert.Equal(t, c.algorithm, header.Get("X-Amz-Server-Side-Encryption"))
+			assert.Equal(t, c.keyID, header.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id"))
+		})
+	}
+}
+
+func TestManager_Progress(t *testing.T) {
+	t.Parallel()
+	server, headers := fakeS3(t)
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar")
+	content := bytes.Repeat([]byte("a"), 1<<20)
+
+	var transferred []int64
+	_, err := m.UploadWithOptions(context.Background(), "progress", bytes.NewReader(content), WithProgress(func(bytesTransferred, total int64) {
+		assert.Equal(t, int64(len(content)), total)
+		transferred = append(transferred, bytesTransferred)
+	}))
+	assert.NoError(t, err)
+	<-headers
+	assert.True(t, len(transferred) > 1)
+	for i := 1; i < len(transferred); i++ {
+		assert.Greater(t, transferred[i], transferred[i-1])
+	}
+	assert.Equal(t, int64(len(content)), transferred[len(transferred)-1])
+}
+
+func TestManager_Timeouts(t *testing.T) {
+	t.Parallel()
+	m := NewManager("key", "secret", "", "us-east-1", "bar")
+	assert.Equal(t, -1, *m.sess.Config.MaxRetries)
+
+	slow := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		writer.Header().Set("ETag", `"etag"`)
+	}))
+	defer slow.Close()
+
+	m = NewManager("key", "secret", slow.URL, "us-east-1", "bar", WithMaxRetries(0), WithRequestTimeout(50*time.Millisecond))
+	assert.Equal(t, 0, *m.sess.Config.MaxRetries)
+	_, err := m.Upload(context.Background(), "slow", strings.NewReader("hello"))
+	assert.Error(t, err)
+
+	server, _ := fakeS3(t)
+	m = NewManager("key", "secret", server.URL, "us-east-1", "bar", WithFetchTimeout(50*time.Millisecond))
+	_, err = m.UploadFromUrl(context.Background(), slow.URL)
+	assert.Error(t, err)
+}
+
+func TestManager_CopySource(t *testing.T) {
+	t.Parallel()
+	server, headers := fakeS3(t)
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar", WithPathPrefix("prefix/"))
+
+	assert.NoError(t, m.Copy(context.Background(), "a b.txt", "c.txt"))
+	assert.Equal(t, "bar/prefix/a%20b.txt", (<-headers).Get("X-Amz-Copy-Source"))
+
+	assert.NoError(t, m.CopyFrom(context.Background(), "foo", "a.txt", "c.txt"))
+	assert.Equal(t, "foo/prefix/a.txt", (<-headers).Get("X-Amz-Copy-Source"))
+}
+
+func TestManager_Copy(t *testing.T) {
+	m := setupManager()
+	content := strings.Repeat("copy", 1000)
+	_, err := m.Upload(context.Background(), "copy-src", strings.NewReader(content))
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Copy(context.Background(), "copy-src.txt", "copy-dst.txt"))
+	var buf bytes.Buffer
+	assert.NoError(t, m.Download(context.Background(), "copy-dst.txt", &buf))
+	assert.Equal(t, content, buf.String())
+
+	_ = m.CreateBucket(context.Background(), "foo")
+	assert.NoError(t, m.CopyFrom(context.Background(), envDefaultS3Bucket, "copy-src.txt", "copy-dst.txt", WithBucket("foo")))
+	buf.Reset()
+	assert.NoError(t, m.Download(context.Background(), "copy-dst.txt", &buf, WithBucket("foo")))
+	assert.Equal(t, content, buf.String())
+}
+
+func TestManager_URLBuilder(t *testing.T) {
+	t.Parallel()
+	server, headers := fakeS3(t)
+	m := NewManager(
+		"key",
+		"secret",
+		server.URL,
+		"us-east-1",
+		"bar",
+		WithPathPrefix("prefix/"),
+		WithURLBuilder(func(bucket, key, location string) (url string) {
+			return "https://cdn.example.com/" + bucket + "/" + key
+		}),
+	)
+	newURL, err := m.Upload(context.Background(), "page", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	<-headers
+	assert.Equal(t, "https://cdn.example.com/bar/prefix/page.txt", newURL)
+
+	newURL, err = m.UploadWithOptions(context.Background(), "page", strings.NewReader("hello"), WithBucket("foo"))
+	assert.NoError(t, err)
+	<-headers
+	assert.Equal(t, "https://cdn.example.com/foo/prefix/page.txt", newURL)
+
+	m = NewManager("key", "secret", server.URL, "us-east-1", "bar", WithLocationFunc(func(location string) (url string) {
+		return strings.Replace(location, server.URL, "https://cdn.example.com", 1)
+	}))
+	newURL, err = m.Upload(context.Background(), "page", strings.NewReader("hello"))
+	assert.NoError(t, err)
+	<-headers
+	assert.Equal(t, "https://cdn.example.com/bar/page.txt", newURL)
+}
+
+func TestManager_MaxConcurrentUploads(t *testing.T) {
+	t.Parallel()
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		_, _ = ioutil.ReadAll(request.Body)
+		time.Sleep(20 * time.Millisecond)
+		writer.Header().Set("ETag", `"etag"`)
+	}))
+	defer server.Close()
+	m := NewManager("key", "secret", server.URL, "us-east-1", "bar", WithMaxConcurrentUploads(2))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%2 == 0 {
+				_, err = m.Upload(context.Background(), "limited", strings.NewReader("hello"))
+			} else {
+				_, err = m.UploadFromUrl(context.Background(), server.URL+"/source")
+			}
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&maxInFlight))
+
+	// waiting respects the context.
+	release, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+	release2, err := m.acquire(context.Background())
+	assert.NoError(t, err)
+	defer release2()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = m.Upload(ctx, "limited", strings.NewReader("hello"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestManager_Presign(t *testing.T) {
+	t.Parallel()
+	m := NewManager("key", "secret", "http://localhost:9000", "us-east-1", "bar", WithTracer(mocktracer.New()), WithPathPrefix("prefix/"))
+	for _, presign := range []func(context.Context, string, time.Duration, ...CallOption) (string, error){m.PresignGet, m.PresignPut} {
+		rawURL, err := presign(context.Background(), "file.png", 10*time.Minute)
+		assert.NoError(t, err)
+		u, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+		assert.Equal(t, "/bar/prefix/file.png", u.Path)
+		assert.Equal(t, "600", u.Query().Get("X-Amz-Expires"))
+		assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+		assert.Equal(t, "host", u.Query().Get("X-Amz-SignedHeaders"))
+	}
+
+	for _, presign := range []func(context.Context, string, time.Duration, ...CallOption) (string, error){m.PresignGet, m.PresignPut} {
+		rawURL, err := presign(context.Background(), "file.png", time.Minute, WithBucket("foo"))
+		assert.NoError(t, err)
+		assert.Contains(t, rawURL, "/foo/prefix/file.png")
+	}
+}
+
+func TestManager_callConfig(t *testing.T) {
+	t.Parallel()
+	m := NewManager("", "", "", "", "bar")
+	assert.Equal(t, "bar", m.callConfig(nil).bucket)
+	assert.Equal(t, "foo", m.callConfig([]CallOption{WithBucket("foo")}).bucket)
 }
 
 func setupManager() *Manager {
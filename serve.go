@@ -15,11 +15,13 @@ import (
 	"github.com/DoNewsCode/core/di"
 	"github.com/DoNewsCode/core/events"
 	"github.com/DoNewsCode/core/logging"
+	"github.com/DoNewsCode/core/srvhttp"
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/mux"
 	"github.com/oklog/run"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/robfig/cron/v3"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
@@ -32,9 +34,10 @@ type serveIn struct {
 	Config     contract.ConfigAccessor
 	Logger     log.Logger
 	Container  contract.Container
-	HTTPServer *http.Server `optional:"true"`
-	GRPCServer *grpc.Server `optional:"true"`
-	Cron       *cron.Cron   `optional:"true"`
+	HTTPServer *http.Server        `optional:"true"`
+	GRPCServer *grpc.Server        `optional:"true"`
+	Cron       *cron.Cron          `optional:"true"`
+	Gatherer   prometheus.Gatherer `optional:"true"`
 }
 
 func NewServeModule(in serveIn) serveModule {
@@ -66,6 +69,17 @@ func (s serveIn) httpServe(ctx context.Context, logger logging.LevelLogger) (fun
 	router := mux.NewRouter()
 	s.Container.ApplyRouter(router)
 
+	// The metrics route is registered after the HTTPProviders, so a provider
+	// serving the same path takes precedence. Middlewares added by providers
+	// with router.Use apply to the metrics route as well.
+	if s.Gatherer != nil && !s.Config.Bool("http.metrics.disable") {
+		path := s.Config.String("http.metrics.path")
+		if path == "" {
+			path = "/metrics"
+		}
+		srvhttp.ProvideMetricsRoute(router, s.Gatherer, path)
+	}
+
 	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
 		tpl, _ := route.GetPathTemplate()
 		level.Debug(logger).Log("service", "http", "path", tpl)
@@ -167,6 +181,16 @@ func (s serveIn) signalWatch(ctx context.Context, logger logging.LevelLogger) (f
 		}, nil
 }
 
+// advance moves the container to the given lifecycle state, if the container
+// tracks it.
+func advance(c contract.Container, to container.State) {
+	if lc, ok := c.(interface {
+		Advance(to container.State) error
+	}); ok {
+		_ = lc.Advance(to)
+	}
+}
+
 func newServeCmd(s serveIn) *cobra.Command {
 	var serveCmd = &cobra.Command{
 		Use:   "serve",
@@ -182,6 +206,8 @@ func newServeCmd(s serveIn) *cobra.Command {
 			for _, m := range s.Container.Modules() {
 				l.Debugf("load module: %T", m)
 			}
+			advance(s.Container, container.Built)
+			advance(s.Container, container.Starting)
 
 			// Add serve and signalWatch
 			serves := []runGroupFunc{
@@ -205,6 +231,18 @@ func newServeCmd(s serveIn) *cobra.Command {
 			// Additional run groups
 			s.Container.ApplyRunGroup(&g)
 
+			// Track the lifecycle state. Running is reached when the run group
+			// starts this actor, alongside the others, not once they serve.
+			running := make(chan struct{})
+			g.Add(func() error {
+				advance(s.Container, container.Running)
+				<-running
+				return nil
+			}, func(err error) {
+				advance(s.Container, container.Draining)
+				close(running)
+			})
+
 			if err := g.Run(); err != nil {
 				return err
 			}
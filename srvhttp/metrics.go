@@ -2,6 +2,7 @@ package srvhttp
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -13,3 +14,10 @@ type MetricsModule struct{}
 func (m MetricsModule) ProvideHTTP(router *mux.Router) {
 	router.PathPrefix("/metrics").Handler(promhttp.Handler())
 }
+
+// ProvideMetricsRoute exposes the metrics collected by the gatherer at the path.
+// Package core calls it when serving HTTP if a prometheus.Gatherer is available
+// in the dependency graph, so most applications don't need to call it directly.
+func ProvideMetricsRoute(router *mux.Router, gatherer prometheus.Gatherer, path string) {
+	router.PathPrefix(path).Handler(promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+}